@@ -0,0 +1,181 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// CredentialProvider: supplies the username/password used to (re-)authenticate
+// A Client with a CredentialProvider retains access to the credentials, and
+// transparently gets a new Auth Token when the API rejects the current one.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (username, password string, err error)
+}
+
+// StaticCredentials: a CredentialProvider returning a fixed username/password
+type StaticCredentials struct {
+	Username string
+	Password string
+}
+
+// Credentials: implements CredentialProvider
+func (s StaticCredentials) Credentials(ctx context.Context) (string, string, error) {
+	return s.Username, s.Password, nil
+}
+
+// WithCredentialProvider: authenticate using the given CredentialProvider
+// When an API call is rejected as unauthorized (e.g. the Auth Token expired
+// or was revoked), the Client gets a new Auth Token once via GetNewAuthToken
+// and replays the original request. Concurrent callers share that refresh.
+func WithCredentialProvider(provider CredentialProvider) ClientOption {
+	return func(c *Client) {
+		c.credentials = provider
+	}
+}
+
+// tokenRefresh: one in-flight authentication, shared by concurrent callers
+type tokenRefresh struct {
+	done       chan struct{}
+	auth_token string
+	err        error
+}
+
+// currentAuthToken: returns the Auth Token to use, authenticating first
+// if this Client does not have one yet
+func (c *Client) currentAuthToken(ctx context.Context) (string, error) {
+
+	c.mu.Lock()
+	auth_token := c.authToken
+	c.mu.Unlock()
+
+	if auth_token != "" {
+		return auth_token, nil
+	}
+
+	if c.tokens != nil {
+		stored_token, err := c.tokens.LoadToken(c.appID)
+		if err != nil {
+			return "", fmt.Errorf("gomojo: unable to load Auth Token from store: %w", err)
+		}
+
+		if stored_token != "" {
+			c.mu.Lock()
+			if c.authToken == "" {
+				c.authToken = stored_token
+			}
+			auth_token = c.authToken
+			c.mu.Unlock()
+
+			return auth_token, nil
+		}
+	}
+
+	return c.refreshAuthToken(ctx, "")
+}
+
+// refreshAuthToken: gets a new Auth Token to replace stale_token
+// If another caller already replaced stale_token, or is doing so right now,
+// its Auth Token is used instead of authenticating again. The shared
+// authentication does not depend on the context of the caller starting it:
+// each caller only gives up waiting for it when its own ctx is done.
+func (c *Client) refreshAuthToken(ctx context.Context, stale_token string) (string, error) {
+
+	c.mu.Lock()
+
+	if c.authToken != "" && c.authToken != stale_token {
+		auth_token := c.authToken
+		c.mu.Unlock()
+		return auth_token, nil
+	}
+
+	refresh := c.refresh
+	if refresh == nil {
+		refresh = &tokenRefresh{done: make(chan struct{})}
+		c.refresh = refresh
+		go c.runRefresh(context.WithoutCancel(ctx), refresh)
+	}
+
+	c.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+		return refresh.auth_token, refresh.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// runRefresh: authenticates for refresh, then wakes up its waiters
+// ctx carries the values (e.g. for logging) but not the cancellation of the
+// caller which started the refresh; it is limited by WithTimeout instead.
+func (c *Client) runRefresh(ctx context.Context, refresh *tokenRefresh) {
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	refresh.auth_token, refresh.err = c.authenticate(ctx)
+
+	// A failure to persist the new Auth Token does not fail the API call:
+	// the Auth Token itself is valid, and is kept in memory.
+	if refresh.err == nil && c.tokens != nil {
+		if err := c.tokens.SaveToken(c.appID, refresh.auth_token); err != nil {
+			c.logWarn(ctx, "gomojo: unable to save Auth Token to store", err)
+		}
+	}
+
+	c.mu.Lock()
+	if refresh.err == nil {
+		c.authToken = refresh.auth_token
+	}
+	c.refresh = nil
+	c.mu.Unlock()
+
+	close(refresh.done)
+}
+
+// authenticate: gets a new Auth Token with the credentials of this Client
+// Without a CredentialProvider, the username/password given to WithUserPass
+// are only used until an Auth Token was obtained, and blanked out afterwards.
+func (c *Client) authenticate(ctx context.Context) (string, error) {
+
+	var username, password string
+	var err error
+
+	if c.credentials != nil {
+		username, password, err = c.credentials.Credentials(ctx)
+		if err != nil {
+			return "", fmt.Errorf("gomojo: unable to get credentials: %w", err)
+		}
+	} else {
+		c.mu.Lock()
+		username, password = c.username, c.password
+		c.mu.Unlock()
+	}
+
+	auth_token, err := c.GetNewAuthTokenContext(ctx, username, password)
+
+	if err == nil && auth_token == "" {
+		err = errors.New("empty Auth Token")
+	}
+	if err != nil {
+		return "", fmt.Errorf("gomojo: unable to get a valid Auth Token from API: %w", err)
+	}
+
+	if c.credentials == nil {
+		c.mu.Lock()
+		c.username = ""
+		c.password = ""
+		c.mu.Unlock()
+	}
+
+	return auth_token, nil
+}
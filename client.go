@@ -0,0 +1,145 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAPIVersion: API version used when no WithAPIVersion option is given
+const DefaultAPIVersion = "1"
+
+// Base URL presets for WithBaseURL
+const (
+	// ProductionBaseURL: root of the live Instamojo REST API (the default)
+	ProductionBaseURL = "https://www.instamojo.com/api/"
+
+	// SandboxBaseURL: root of the Instamojo test environment REST API
+	SandboxBaseURL = "https://test.instamojo.com/api/"
+)
+
+// Client: one Instamojo API client. Each Client carries its own App ID,
+// credentials, API version, base URL and http.Client, so several Instamojo
+// accounts can be used side by side in the same process.
+// A Client is safe for concurrent use by multiple goroutines: concurrent
+// calls needing an Auth Token share a single in-flight authentication.
+type Client struct {
+	appID        string
+	authToken    string
+	apiVersion   string
+	baseURL      string
+	httpClient   *http.Client
+	retry        RetryPolicy
+	interceptors []Interceptor
+	logger       *slog.Logger
+
+	skipValidation bool
+	uploadProgress UploadProgressFunc
+	driftReporter  DriftReporter
+	strictDecoding bool
+
+	// Settings of the http.Client created by NewClient (see transport.go)
+	roundTripper http.RoundTripper
+	transport    *http.Transport
+	timeout      time.Duration
+
+	// username/password are only kept until they got an Auth Token
+	// (a failed authentication can be retried). Afterwards, they are blanked out.
+	// A CredentialProvider (credentials) is retained instead.
+	username    string
+	password    string
+	credentials CredentialProvider
+	tokens      TokenStore
+
+	// mu guards authToken, username/password and refresh
+	mu      sync.Mutex
+	refresh *tokenRefresh
+
+	initDone bool
+}
+
+// ClientOption: configures a Client in NewClient
+type ClientOption func(*Client)
+
+// WithAuthToken: use a pre-generated Auth Token
+func WithAuthToken(auth_token string) ClientOption {
+	return func(c *Client) {
+		c.authToken = auth_token
+	}
+}
+
+// WithUserPass: authenticate with username/password on the first API call
+func WithUserPass(username, password string) ClientOption {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithAPIVersion: use the given API version instead of DefaultAPIVersion
+func WithAPIVersion(api_ver string) ClientOption {
+	return func(c *Client) {
+		c.apiVersion = api_ver
+	}
+}
+
+// WithBaseURL: use the given API root (e.g. SandboxBaseURL, or a local
+// stand-in server) instead of ProductionBaseURL. The API version and
+// endpoint are appended to it, e.g. <base URL>1/offer/
+func WithBaseURL(base_url string) ClientOption {
+	return func(c *Client) {
+		if base_url != "" && !strings.HasSuffix(base_url, "/") {
+			base_url += "/"
+		}
+		c.baseURL = base_url
+	}
+}
+
+// NewClient: creates a new Client for the given App ID
+// Inputs: (App ID string, options ...ClientOption)
+// Returns: (*Client)
+// One of WithAuthToken, WithUserPass, WithCredentialProvider or WithTokenStore
+// must be given for API calls to succeed.
+func NewClient(app_id string, opts ...ClientOption) *Client {
+
+	c := &Client{
+		appID:      app_id,
+		apiVersion: DefaultAPIVersion,
+		baseURL:    ProductionBaseURL,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.initHTTPClient()
+
+	c.initDone = c.apiVersion != "" && c.appID != "" && c.baseURL != "" &&
+		(c.authToken != "" || (c.username != "" && c.password != "") || c.credentials != nil || c.tokens != nil)
+
+	return c
+}
+
+// GetCurrentAuthToken: returns the current Auth Token of this Client
+// Inputs: None
+// Returns (Auth Token string)
+func (c *Client) GetCurrentAuthToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.authToken
+}
+
+// SetCurrentAuthToken: sets the current Auth Token of this Client
+// Inputs: (Auth Token string)
+func (c *Client) SetCurrentAuthToken(auth_token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.authToken = auth_token
+}
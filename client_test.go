@@ -0,0 +1,157 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dotmanish/gomojo"
+	"github.com/dotmanish/gomojo/gomojotest"
+)
+
+// listOffersInParallel: calls ListOffers from n goroutines at once
+func listOffersInParallel(t *testing.T, client *gomojo.Client, n int) {
+
+	t.Helper()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.ListOffers()
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("ListOffers: %v", err)
+		}
+	}
+}
+
+func TestParallelCallsAuthenticateOnce(t *testing.T) {
+
+	const parallel = 20
+
+	srv := gomojotest.NewServer()
+	defer srv.Close()
+
+	srv.AddUser("user", "secret")
+	srv.SeedOffers(gomojo.Offer{Title: "Test Product"})
+
+	client := srv.NewClient(gomojo.WithAuthToken(""),
+		gomojo.WithCredentialProvider(gomojo.StaticCredentials{Username: "user", Password: "secret"}))
+
+	// First calls: no Auth Token yet
+	listOffersInParallel(t, client, parallel)
+
+	if auths := len(srv.RequestsFor(gomojotest.EndpointAuth)); auths != 1 {
+		t.Fatalf("got %d auth requests for the first calls, want 1", auths)
+	}
+
+	// The Auth Token expires: all callers get a 401, one refreshes it
+	srv.RevokeToken(client.GetCurrentAuthToken())
+
+	listOffersInParallel(t, client, parallel)
+
+	if auths := len(srv.RequestsFor(gomojotest.EndpointAuth)); auths != 2 {
+		t.Fatalf("got %d auth requests after the Auth Token was revoked, want 2", auths)
+	}
+
+	if list := len(srv.RequestsFor(gomojotest.EndpointListOffers)); list < 2*parallel {
+		t.Errorf("got %d listoffers requests, want at least %d", list, 2*parallel)
+	}
+}
+
+func TestSharedRefreshOutlivesFirstCaller(t *testing.T) {
+
+	srv := gomojotest.NewServer()
+	defer srv.Close()
+
+	srv.AddUser("user", "secret")
+	srv.SetFault(gomojotest.EndpointAuth, gomojotest.Fault{Delay: 200 * time.Millisecond, Times: 1})
+
+	client := srv.NewClient(gomojo.WithAuthToken(""),
+		gomojo.WithCredentialProvider(gomojo.StaticCredentials{Username: "user", Password: "secret"}))
+
+	// The first caller starts the refresh, and gives up before it completes
+	first_err := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := client.ListOffersContext(ctx)
+		first_err <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	// The second caller, without a deadline, waits for the shared refresh
+	if _, err := client.ListOffers(); err != nil {
+		t.Errorf("ListOffers without deadline: %v", err)
+	}
+
+	if err := <-first_err; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ListOffers with deadline: got %v, want context.DeadlineExceeded", err)
+	}
+
+	if auths := len(srv.RequestsFor(gomojotest.EndpointAuth)); auths != 1 {
+		t.Errorf("got %d auth requests, want 1", auths)
+	}
+}
+
+func TestUserPassSurvivesFailedAuthentication(t *testing.T) {
+
+	tests := map[string]struct {
+		fault gomojotest.Fault
+		opts  []gomojo.ClientOption
+	}{
+		"server error": {
+			fault: gomojotest.Fault{StatusCode: 503, Message: "Service Unavailable", Times: 1},
+		},
+		"timeout": {
+			fault: gomojotest.Fault{Delay: 500 * time.Millisecond, Times: 1},
+			opts:  []gomojo.ClientOption{gomojo.WithTimeout(100 * time.Millisecond)},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			srv := gomojotest.NewServer()
+			defer srv.Close()
+
+			srv.AddUser("user", "secret")
+			srv.SetFault(gomojotest.EndpointAuth, test.fault)
+
+			client := srv.NewClient(append([]gomojo.ClientOption{gomojo.WithAuthToken(""),
+				gomojo.WithUserPass("user", "secret")}, test.opts...)...)
+
+			if _, err := client.ListOffers(); err == nil {
+				t.Fatal("first ListOffers succeeded, want the authentication to fail")
+			}
+
+			if _, err := client.ListOffers(); err != nil {
+				t.Fatalf("second ListOffers: %v", err)
+			}
+
+			requests := srv.RequestsFor(gomojotest.EndpointAuth)
+			if last := requests[len(requests)-1]; last.Form.Get("username") != "user" || last.Form.Get("password") != "secret" {
+				t.Errorf("retried authentication sent %v, want the WithUserPass credentials", last.Form)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"sync"
+)
+
+// The package-level functions below are thin wrappers over a default Client,
+// kept for existing users of the package. New code should use NewClient.
+// They keep reporting (API success bool, Message string) instead of an error;
+// Message is the Instamojo message for an *APIError, or the error text otherwise.
+
+var default_client = NewClient("")
+var default_client_mu sync.RWMutex
+
+// defaultClient: returns the current default Client
+func defaultClient() *Client {
+	default_client_mu.RLock()
+	defer default_client_mu.RUnlock()
+	return default_client
+}
+
+// setDefaultClient: replaces the default Client
+func setDefaultClient(c *Client) {
+	default_client_mu.Lock()
+	defer default_client_mu.Unlock()
+	default_client = c
+}
+
+// InitGomojoWithAuthToken: Initialize gomojo with Auth Token
+// Inputs: (API version string, App ID string, Auth Token string)
+func InitGomojoWithAuthToken(api_ver, app_id, auth_token string) {
+
+	if api_ver != "" && app_id != "" && auth_token != "" {
+		setDefaultClient(NewClient(app_id, WithAPIVersion(api_ver), WithAuthToken(auth_token)))
+	}
+}
+
+// InitGomojoWithUserPass: Initialize gomojo with username/password
+// Inputs: (API version string, App ID string, Username string, Password string)
+// Note: The username/password are internally stored in the default Client
+// until an API call got an Auth Token with them. Afterwards, these internal
+// variables are blanked out.
+func InitGomojoWithUserPass(api_ver, app_id, username, password string) {
+
+	if api_ver != "" && app_id != "" && username != "" && password != "" {
+		setDefaultClient(NewClient(app_id, WithAPIVersion(api_ver), WithUserPass(username, password)))
+	}
+}
+
+// GetCurrentAuthToken: returns the current Auth Token
+// Inputs: None
+// Returns (Auth Token string)
+func GetCurrentAuthToken() string {
+	return defaultClient().GetCurrentAuthToken()
+}
+
+// SetCurrentAuthToken: sets the current Auth Token
+// Inputs: (Auth Token string)
+func SetCurrentAuthToken(auth_token string) {
+	defaultClient().SetCurrentAuthToken(auth_token)
+}
+
+// ListOffers: retrieves the list of all offers created under the given App(ID)
+// Inputs: None
+// Returns: (Offer object array, API success bool, Message string)
+func ListOffers() ([]Offer, bool, string) {
+	offers, err := defaultClient().ListOffers()
+	success, message := errorMessage(err)
+	return offers, success, message
+}
+
+// GetOfferDetails: retrieves the details of a particular offer
+// Inputs: (Offer-Slug string)
+// Returns: (Offer object, API success bool, Message string)
+func GetOfferDetails(offer_slug string) (Offer, bool, string) {
+	offer, err := defaultClient().GetOfferDetails(offer_slug)
+	success, message := errorMessage(err)
+	return offer, success, message
+}
+
+// ArchiveOffer: archives an existing Offer
+// Inputs: (Offer Slug string)
+// Returns: (API success bool, Message string)
+func ArchiveOffer(offer_slug string) (bool, string) {
+	return errorMessage(defaultClient().ArchiveOffer(offer_slug))
+}
+
+// UnarchiveOffer: restores an archived Offer, by setting its status back to Live
+// Inputs: (Offer Slug string)
+// Returns: (Offer object, API success bool, Message string)
+func UnarchiveOffer(offer_slug string) (Offer, bool, string) {
+	offer, err := defaultClient().UnarchiveOffer(offer_slug)
+	success, message := errorMessage(err)
+	return offer, success, message
+}
+
+// UploadFile: uploads a File (content) or Cover Image
+// Inputs: (File Path string)
+// Returns: (API success bool, APUI Message string, UploadURL string, Upload-File JSON string)
+func UploadFile(file_path string) (bool, string, string, string) {
+	result, err := defaultClient().UploadFile(file_path)
+	success, message := errorMessage(err)
+	return success, message, result.UploadURL, result.JSON
+}
+
+// CreateOffer: create a new offer
+// Inputs: (Offer object)
+// Returns: (Offer object, API success bool, Message string)
+func CreateOffer(offer Offer) (Offer, bool, string) {
+	offer, err := defaultClient().CreateOffer(offer)
+	success, message := errorMessage(err)
+	return offer, success, message
+}
+
+// UpdateOffer: update an existing offer
+// All fields of the Offer object (but Status) are sent, blank or not.
+// Inputs: (Offer-slug string, Offer object)
+// Returns: (Offer object, API success bool, Message string)
+func UpdateOffer(offer_slug string, offer Offer) (Offer, bool, string) {
+	offer, err := defaultClient().UpdateOffer(offer_slug, FullOfferPatch(offer))
+	success, message := errorMessage(err)
+	return offer, success, message
+}
+
+// GetNewAuthToken: gets a new Auth Token
+// Inputs: (Username string, Password string)
+// Returns: (Auth Token string, API success bool, Message string)
+func GetNewAuthToken(username, password string) (string, bool, string) {
+	auth_token, err := defaultClient().GetNewAuthToken(username, password)
+	success, message := errorMessage(err)
+	return auth_token, success, message
+}
+
+// DeleteAuthToken: deletes an existing Auth Token
+// Inputs: (Auth Token string)
+// Returns: (API success bool, Message string)
+func DeleteAuthToken(auth_token string) (bool, string) {
+	return errorMessage(defaultClient().DeleteAuthToken(auth_token))
+}
@@ -0,0 +1,56 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"testing"
+
+	"github.com/dotmanish/gomojo"
+)
+
+func TestPackageFunctionsBeforeInit(t *testing.T) {
+
+	const want = "Please call gomojo.InitGomojoWithAuthToken() or gomojo.InitGomojoWithUserPass() first."
+
+	// Incomplete parameters leave the default Client uninitialized
+	gomojo.InitGomojoWithAuthToken("1", "", "token")
+
+	results := map[string]func() (bool, string){
+		"ListOffers": func() (bool, string) {
+			_, success, message := gomojo.ListOffers()
+			return success, message
+		},
+		"GetOfferDetails": func() (bool, string) {
+			_, success, message := gomojo.GetOfferDetails("a")
+			return success, message
+		},
+		"ArchiveOffer": func() (bool, string) {
+			return gomojo.ArchiveOffer("a")
+		},
+		"UnarchiveOffer": func() (bool, string) {
+			_, success, message := gomojo.UnarchiveOffer("a")
+			return success, message
+		},
+		"UploadFile": func() (bool, string) {
+			success, message, _, _ := gomojo.UploadFile("file.txt")
+			return success, message
+		},
+		"CreateOffer": func() (bool, string) {
+			_, success, message := gomojo.CreateOffer(gomojo.Offer{Title: "A", BasePrice: "10"})
+			return success, message
+		},
+		"UpdateOffer": func() (bool, string) {
+			_, success, message := gomojo.UpdateOffer("a", gomojo.Offer{Title: "A", BasePrice: "10"})
+			return success, message
+		},
+	}
+
+	for name, call := range results {
+		if success, message := call(); success || message != want {
+			t.Errorf("%s: got %v, %q, want false, %q", name, success, message, want)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DriftKind: how a response differs from what gomojo expects
+type DriftKind string
+
+const (
+	DriftUnknownField DriftKind = "unknown field" // in the response, but not known to gomojo
+	DriftMissingField DriftKind = "missing field" // expected, but not in the response
+	DriftTypeMismatch DriftKind = "type mismatch" // of another JSON type than expected
+)
+
+// Drift: one difference between a response and what gomojo expects
+type Drift struct {
+	Kind   DriftKind
+	Field  string // path of the field, e.g. "offers[].base_price"
+	Detail string // the JSON type seen (and expected, for DriftTypeMismatch)
+}
+
+// String: e.g. "offer.base_price: type mismatch (number, expected string)"
+func (d Drift) String() string {
+
+	if d.Detail == "" {
+		return d.Field + ": " + string(d.Kind)
+	}
+
+	return d.Field + ": " + string(d.Kind) + " (" + d.Detail + ")"
+}
+
+// DriftReport: the drift found in one successful API response
+type DriftReport struct {
+	Operation string // e.g. "listoffers", "upload", "do"
+	Method    string
+	Endpoint  string
+	Drifts    []Drift
+}
+
+// DriftReporter: receives the DriftReport of every response with drift
+type DriftReporter func(report DriftReport)
+
+// DriftError: a response with drift, returned in strict mode
+// errors.Is(err, ErrSchemaDrift) holds for it.
+type DriftError struct {
+	Drifts []Drift
+}
+
+// Error: implements the error interface
+func (e *DriftError) Error() string {
+	return ErrSchemaDrift.Error() + ": " + driftList(e.Drifts)
+}
+
+// Is: matches ErrSchemaDrift
+func (e *DriftError) Is(target error) bool {
+	return target == ErrSchemaDrift
+}
+
+// WithDriftReporter: check every successful response for fields Instamojo
+// added, removed or retyped, and pass the drift found to reporter
+func WithDriftReporter(reporter DriftReporter) ClientOption {
+	return func(c *Client) {
+		c.driftReporter = reporter
+	}
+}
+
+// WithStrictDecoding: fail calls whose response has drift, with an
+// *APIError whose Cause is a *DriftError (instead of silently dropping or
+// zero-filling the affected fields)
+func WithStrictDecoding() ClientOption {
+	return func(c *Client) {
+		c.strictDecoding = true
+	}
+}
+
+// DetectDrift: checks a recorded successful response body of the given
+// operation (e.g. "listoffers", "offerdetails", "upload") for drift
+// Inputs: (Operation string, Response Body []byte)
+// Returns: (Drift array, error)
+func DetectDrift(operation string, body []byte) ([]Drift, error) {
+
+	var response interface{}
+	if operation == "upload" {
+		response = new(UploadResult)
+	} else if api_endpoint, ok := endpoints[operation]; ok && api_endpoint.response != nil {
+		response = api_endpoint.response()
+	} else {
+		return nil, fmt.Errorf("gomojo: unknown API operation %q", operation)
+	}
+
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("%w: not JSON", ErrInvalidResponse)
+	}
+
+	return detectDrift(body, response, endpoints[operation].expect), nil
+}
+
+// checkDrift: reports the drift of call's response, if a DriftReporter is
+// set, and returns a *DriftError in strict mode
+func (c *Client) checkDrift(call *Call) *DriftError {
+
+	if (c.driftReporter == nil && !c.strictDecoding) || call.Result == nil {
+		return nil
+	}
+
+	drifts := detectDrift(call.ResponseBody, call.Result, endpoints[call.Operation].expect)
+	if len(drifts) == 0 {
+		return nil
+	}
+
+	if c.driftReporter != nil {
+		c.driftReporter(DriftReport{
+			Operation: call.Operation,
+			Method:    call.Request.Method,
+			Endpoint:  call.Endpoint,
+			Drifts:    drifts,
+		})
+	}
+
+	if c.strictDecoding {
+		return &DriftError{Drifts: drifts}
+	}
+
+	return nil
+}
+
+// driftSchema: implemented by results whose JSON fields are not their
+// struct fields (i.e. Envelope)
+type driftSchema interface {
+	schema() reflect.Type
+}
+
+// schema: the struct type with Envelope's JSON fields
+func (e *Envelope[T]) schema() reflect.Type {
+
+	fields := []reflect.StructField{
+		{Name: "Success", Type: reflect.TypeOf(e.Success), Tag: `json:"success"`},
+		{Name: "Message", Type: reflect.TypeOf(e.Message), Tag: `json:"message"`},
+	}
+	if e.field != "" {
+		fields = append(fields, reflect.StructField{Name: "Data", Type: reflect.TypeOf(&e.Data).Elem(), Tag: reflect.StructTag(`json:"` + e.field + `"`)})
+	}
+
+	return reflect.StructOf(fields)
+}
+
+// detectDrift: compares body with the type of result, and checks that the
+// expected field paths are present
+func detectDrift(body []byte, result interface{}, expect []string) []Drift {
+
+	var schema reflect.Type
+	if schema_result, ok := result.(driftSchema); ok {
+		schema = schema_result.schema()
+	} else {
+		schema = reflect.TypeOf(result)
+	}
+
+	detector := &driftDetector{seen: make(map[Drift]bool)}
+	detector.walk(body, schema, "")
+
+	var missing []string
+	for _, path := range expect {
+		if hasMissingParent(path, missing) || !missingField(body, strings.Split(path, ".")) {
+			continue
+		}
+		missing = append(missing, path)
+		detector.add(Drift{Kind: DriftMissingField, Field: path})
+	}
+
+	sort.Slice(detector.drifts, func(i, j int) bool {
+		if detector.drifts[i].Field != detector.drifts[j].Field {
+			return detector.drifts[i].Field < detector.drifts[j].Field
+		}
+		return detector.drifts[i].Kind < detector.drifts[j].Kind
+	})
+
+	return detector.drifts
+}
+
+// driftDetector: collects the drift found by walk, without duplicates
+// (e.g. the same unknown field in every offer of a list)
+type driftDetector struct {
+	drifts []Drift
+	seen   map[Drift]bool
+}
+
+// add: records drift, unless already recorded
+func (d *driftDetector) add(drift Drift) {
+
+	if !d.seen[drift] {
+		d.seen[drift] = true
+		d.drifts = append(d.drifts, drift)
+	}
+}
+
+// walk: compares the JSON value raw at path with type t
+func (d *driftDetector) walk(raw json.RawMessage, t reflect.Type, path string) {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	seen_type := jsonType(raw)
+	expected_type := expectedJSONType(t)
+	if seen_type == "null" || expected_type == "" {
+		return
+	}
+	if seen_type != expected_type {
+		d.add(Drift{Kind: DriftTypeMismatch, Field: path, Detail: seen_type + ", expected " + expected_type})
+		return
+	}
+
+	switch t.Kind() {
+
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		json.Unmarshal(raw, &fields)
+
+		known := jsonFields(t)
+		for name, value := range fields {
+			field_path := joinPath(path, name)
+			if field, ok := known[strings.ToLower(name)]; ok {
+				d.walk(value, field.Type, field_path)
+			} else {
+				d.add(Drift{Kind: DriftUnknownField, Field: field_path, Detail: jsonType(value)})
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		json.Unmarshal(raw, &items)
+
+		for _, item := range items {
+			d.walk(item, t.Elem(), path+"[]")
+		}
+	}
+}
+
+// jsonType: the JSON type of raw, e.g. "object" or "string"
+func jsonType(raw json.RawMessage) string {
+
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return "null"
+	}
+
+	switch raw[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	}
+
+	return "number"
+}
+
+// expectedJSONType: the JSON type decoded into type t ("" if any)
+func expectedJSONType(t reflect.Type) string {
+
+	if t == reflect.TypeOf(json.RawMessage{}) {
+		return ""
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string" // []byte is base64
+		}
+		return "array"
+	case reflect.Array:
+		return "array"
+	}
+
+	return ""
+}
+
+// missingField: whether the field at the path segments is absent from raw
+// A segment ending in "[]" is an array, whose every element is checked.
+func missingField(raw json.RawMessage, segments []string) bool {
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil {
+		return false // a type mismatch, not a missing field
+	}
+
+	name, each := strings.CutSuffix(segments[0], "[]")
+	key, ok := fieldKey(fields, name)
+	if !ok {
+		return true
+	}
+	value := fields[key]
+
+	if !each {
+		return missingField(value, segments[1:])
+	}
+
+	var items []json.RawMessage
+	json.Unmarshal(value, &items)
+	for _, item := range items {
+		if missingField(item, segments[1:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasMissingParent: whether a parent of path is among the missing paths
+func hasMissingParent(path string, missing []string) bool {
+
+	for _, parent := range missing {
+		if strings.HasPrefix(path, parent+".") || strings.HasPrefix(path, parent+"[]") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// joinPath: the path of field name within path
+func joinPath(path, name string) string {
+
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// driftList: the drifts, as one line
+func driftList(drifts []Drift) string {
+
+	list := make([]string, len(drifts))
+	for i, drift := range drifts {
+		list[i] = drift.String()
+	}
+
+	return strings.Join(list, "; ")
+}
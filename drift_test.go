@@ -0,0 +1,226 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/dotmanish/gomojo"
+	"github.com/dotmanish/gomojo/gomojotest"
+)
+
+// removed: marks a field removed by offerJSON
+var removed = new(struct{})
+
+// offerJSON: an offer with all the fields the Offer Details API returns,
+// with the given fields replaced (or removed)
+func offerJSON(changes map[string]interface{}) map[string]interface{} {
+
+	offer := map[string]interface{}{
+		"title": "Test Product", "slug": "test-product", "status": "Live", "shorturl": "http://imojo.in/abc",
+		"description": "A product", "currency": "INR", "base_price": "10.00", "quantity": "",
+		"start_date": "", "end_date": "", "timezone": "", "venue": "", "redirect_url": "", "note": "",
+	}
+
+	for name, value := range changes {
+		if value == removed {
+			delete(offer, name)
+		} else {
+			offer[name] = value
+		}
+	}
+
+	return offer
+}
+
+// mustJSON: v encoded as JSON
+func mustJSON(t *testing.T, v interface{}) []byte {
+
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("encoding %v: %v", v, err)
+	}
+
+	return data
+}
+
+func TestDetectDrift(t *testing.T) {
+
+	tests := map[string]struct {
+		operation string
+		body      map[string]interface{}
+		drifts    []gomojo.Drift
+	}{
+		"no drift": {
+			operation: "offerdetails",
+			body:      map[string]interface{}{"success": true, "offer": offerJSON(nil)},
+		},
+		"null field": {
+			operation: "offerdetails",
+			body:      map[string]interface{}{"success": true, "offer": offerJSON(map[string]interface{}{"note": nil})},
+		},
+		"unknown field": {
+			operation: "offerdetails",
+			body:      map[string]interface{}{"success": true, "offer": offerJSON(nil), "request_id": "abc"},
+			drifts:    []gomojo.Drift{{Kind: gomojo.DriftUnknownField, Field: "request_id", Detail: "string"}},
+		},
+		"unknown nested field": {
+			operation: "offerdetails",
+			body:      map[string]interface{}{"success": true, "offer": offerJSON(map[string]interface{}{"tags": []string{"a"}})},
+			drifts:    []gomojo.Drift{{Kind: gomojo.DriftUnknownField, Field: "offer.tags", Detail: "array"}},
+		},
+		"missing field": {
+			operation: "deauth",
+			body:      map[string]interface{}{"message": "Auth Token deleted."},
+			drifts:    []gomojo.Drift{{Kind: gomojo.DriftMissingField, Field: "success"}},
+		},
+		"missing nested field": {
+			operation: "offerdetails",
+			body:      map[string]interface{}{"success": true, "offer": offerJSON(map[string]interface{}{"note": removed, "venue": removed})},
+			drifts: []gomojo.Drift{
+				{Kind: gomojo.DriftMissingField, Field: "offer.note"},
+				{Kind: gomojo.DriftMissingField, Field: "offer.venue"},
+			},
+		},
+		"missing parent": {
+			operation: "offerdetails",
+			body:      map[string]interface{}{"success": true},
+			drifts:    []gomojo.Drift{{Kind: gomojo.DriftMissingField, Field: "offer"}},
+		},
+		"retyped field": {
+			operation: "offerdetails",
+			body:      map[string]interface{}{"success": "true", "offer": offerJSON(nil)},
+			drifts:    []gomojo.Drift{{Kind: gomojo.DriftTypeMismatch, Field: "success", Detail: "string, expected boolean"}},
+		},
+		"retyped nested field": {
+			operation: "offerdetails",
+			body:      map[string]interface{}{"success": true, "offer": offerJSON(map[string]interface{}{"base_price": 10})},
+			drifts:    []gomojo.Drift{{Kind: gomojo.DriftTypeMismatch, Field: "offer.base_price", Detail: "number, expected string"}},
+		},
+		"retyped parent": {
+			operation: "offerdetails",
+			body:      map[string]interface{}{"success": true, "offer": []interface{}{offerJSON(nil)}},
+			drifts:    []gomojo.Drift{{Kind: gomojo.DriftTypeMismatch, Field: "offer", Detail: "array, expected object"}},
+		},
+		"list without drift": {
+			operation: "listoffers",
+			body:      map[string]interface{}{"success": true, "offers": []interface{}{offerJSON(nil), offerJSON(nil)}},
+		},
+		"list drift reported once": {
+			operation: "listoffers",
+			body: map[string]interface{}{"success": true, "offers": []interface{}{
+				offerJSON(map[string]interface{}{"shorturl": removed, "tags": []string{}}),
+				offerJSON(map[string]interface{}{"shorturl": removed, "tags": []string{"a"}}),
+				offerJSON(map[string]interface{}{"title": 42}),
+			}},
+			drifts: []gomojo.Drift{
+				{Kind: gomojo.DriftMissingField, Field: "offers[].shorturl"},
+				{Kind: gomojo.DriftUnknownField, Field: "offers[].tags", Detail: "array"},
+				{Kind: gomojo.DriftTypeMismatch, Field: "offers[].title", Detail: "number, expected string"},
+			},
+		},
+		"upload": {
+			operation: "upload",
+			body:      map[string]interface{}{"success": true, "file_id": "1", "filename": "a.txt", "size": "7", "checksum": "abc"},
+			drifts: []gomojo.Drift{
+				{Kind: gomojo.DriftUnknownField, Field: "checksum", Detail: "string"},
+				{Kind: gomojo.DriftTypeMismatch, Field: "size", Detail: "string, expected number"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			drifts, err := gomojo.DetectDrift(test.operation, mustJSON(t, test.body))
+			if err != nil {
+				t.Fatalf("DetectDrift: %v", err)
+			}
+
+			if !reflect.DeepEqual(drifts, test.drifts) {
+				t.Errorf("got drifts %v, want %v", drifts, test.drifts)
+			}
+		})
+	}
+}
+
+func TestDetectDriftErrors(t *testing.T) {
+
+	if _, err := gomojo.DetectDrift("nosuchoperation", []byte(`{"success":true}`)); err == nil {
+		t.Error("DetectDrift of an unknown operation succeeded")
+	}
+
+	if _, err := gomojo.DetectDrift("listoffers", []byte("<html>")); !errors.Is(err, gomojo.ErrInvalidResponse) {
+		t.Errorf("DetectDrift of a non-JSON body: got %v, want ErrInvalidResponse", err)
+	}
+}
+
+func TestDriftReporterAndStrictDecoding(t *testing.T) {
+
+	srv := gomojotest.NewServer()
+	defer srv.Close()
+
+	// Two listed offers have the same unknown field
+	extra := map[string]json.RawMessage{"tags": json.RawMessage(`["new"]`)}
+	srv.SeedOffers(gomojo.Offer{Title: "First", Extra: extra}, gomojo.Offer{Title: "Second", Extra: extra},
+		gomojo.Offer{Title: "Plain"})
+
+	var mu sync.Mutex
+	var reports []gomojo.DriftReport
+	reporter := func(report gomojo.DriftReport) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, report)
+	}
+
+	want := []gomojo.Drift{{Kind: gomojo.DriftUnknownField, Field: "offers[].tags", Detail: "array"}}
+
+	// Lenient: the offers are returned, and the drift is reported once per response
+	client := srv.NewClient(gomojo.WithDriftReporter(reporter))
+	for i := 0; i < 2; i++ {
+		offers, err := client.ListOffers()
+		if err != nil || len(offers) != 3 {
+			t.Fatalf("ListOffers: got %d offers, %v", len(offers), err)
+		}
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("got %d drift reports, want 2", len(reports))
+	}
+	if report := reports[0]; report.Operation != "listoffers" || report.Method != "GET" || !reflect.DeepEqual(report.Drifts, want) {
+		t.Errorf("got drift report %+v, want listoffers GET %v", report, want)
+	}
+
+	// Responses without drift are not reported
+	if _, err := client.GetOfferDetails("plain"); err != nil {
+		t.Fatalf("GetOfferDetails: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Errorf("got a drift report for a response without drift: %+v", reports[2:])
+	}
+
+	// Strict: the call fails
+	reports = nil
+	_, err := srv.NewClient(gomojo.WithStrictDecoding(), gomojo.WithDriftReporter(reporter)).ListOffers()
+
+	if !errors.Is(err, gomojo.ErrSchemaDrift) {
+		t.Fatalf("strict ListOffers: got %v, want ErrSchemaDrift", err)
+	}
+
+	var drift_err *gomojo.DriftError
+	if !errors.As(err, &drift_err) || !reflect.DeepEqual(drift_err.Drifts, want) {
+		t.Errorf("strict ListOffers: got %v, want a DriftError with %v", err, want)
+	}
+	if len(reports) != 1 {
+		t.Errorf("got %d drift reports in strict mode, want 1", len(reports))
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// bodyEncoding: how the parameters of an endpoint are sent
+type bodyEncoding int
+
+const (
+	bodyNone  bodyEncoding = iota // no parameters
+	bodyForm                      // form-encoded request body
+	bodyQuery                     // URL query string
+)
+
+// endpoint: one operation of the Instamojo API
+type endpoint struct {
+	operation string       // logical operation, as in Call.Operation
+	method    string       // HTTP method
+	path      string       // relative to the API version root; {target} is replaced
+	body      bodyEncoding // how the form is sent
+	anonymous bool         // sent without an Auth Token
+
+	// For drift detection (see drift.go): a new (empty) response, and the
+	// paths of the fields every successful response has
+	response func() interface{}
+	expect   []string
+}
+
+// endpoints: the Instamojo API operations wrapped by Client, by operation
+var endpoints = map[string]endpoint{
+	"auth": {
+		operation: "auth", method: "POST", path: "auth/", body: bodyForm, anonymous: true,
+		response: func() interface{} { return NewEnvelope[string]("token") },
+		expect:   []string{"success", "token"},
+	},
+	"deauth": {
+		operation: "deauth", method: "DELETE", path: "auth/{target}/",
+		response: func() interface{} { return NewEnvelope[struct{}]("") },
+		expect:   []string{"success"},
+	},
+	"listoffers": {
+		operation: "listoffers", method: "GET", path: "offer/",
+		response: func() interface{} { return NewEnvelope[[]Offer]("offers") },
+		expect:   append([]string{"success", "offers"}, fieldPaths("offers[].", listOfferFields)...),
+	},
+	"offerdetails": {
+		operation: "offerdetails", method: "GET", path: "offer/{target}/",
+		response: func() interface{} { return NewEnvelope[Offer]("offer") },
+		expect:   append([]string{"success", "offer"}, fieldPaths("offer.", offerFields)...),
+	},
+	"archiveoffer": {
+		operation: "archiveoffer", method: "DELETE", path: "offer/{target}/",
+		response: func() interface{} { return NewEnvelope[struct{}]("") },
+		expect:   []string{"success"},
+	},
+	"getfileuploadurl": {
+		operation: "getfileuploadurl", method: "GET", path: "offer/get_file_upload_url/",
+		response: func() interface{} { return NewEnvelope[string]("upload_url") },
+		expect:   []string{"success", "upload_url"},
+	},
+	"createoffer": {
+		operation: "createoffer", method: "POST", path: "offer/", body: bodyForm,
+		response: func() interface{} { return NewEnvelope[Offer]("offer") },
+		expect:   append([]string{"success", "offer"}, fieldPaths("offer.", offerFields)...),
+	},
+	"updateoffer": {
+		operation: "updateoffer", method: "PATCH", path: "offer/{target}/", body: bodyForm,
+		response: func() interface{} { return NewEnvelope[Offer]("offer") },
+		expect:   append([]string{"success", "offer"}, fieldPaths("offer.", offerFields)...),
+	},
+}
+
+// listOfferFields: the Offer fields the Offers List API populates
+var listOfferFields = []string{"title", "slug", "status", "shorturl"}
+
+// offerFields: the Offer fields the Offer Details API populates
+// (file_upload_json and cover_image_json are only sent to the API)
+var offerFields = []string{
+	"title", "slug", "status", "shorturl", "description", "currency", "base_price", "quantity",
+	"start_date", "end_date", "timezone", "venue", "redirect_url", "note",
+}
+
+// fieldPaths: the paths of fields within prefix
+func fieldPaths(prefix string, fields []string) []string {
+
+	paths := make([]string, len(fields))
+	for i, field := range fields {
+		paths[i] = prefix + field
+	}
+
+	return paths
+}
+
+// expand: the endpoint's path, with {target} replaced by the escaped target
+func (e endpoint) expand(target string) string {
+	return strings.Replace(e.path, "{target}", url.PathEscape(target), 1)
+}
+
+// Do: calls any Instamojo API endpoint, e.g. one this package does not wrap
+// path is relative to the API version root (e.g. "offer/my-offer-slug/").
+// form (may be nil) is sent form-encoded in the request body for POST, PUT
+// and PATCH, and as the query string otherwise (merged with any query
+// string of path). The JSON response is decoded into out (may be nil).
+// Authentication, retries, interceptors and errors are handled as for the
+// other Client methods.
+// Inputs: (Context, HTTP Method string, Path string, Form url.Values, Result interface{})
+// Returns: (error)
+func (c *Client) Do(ctx context.Context, method, path string, form url.Values, out interface{}) error {
+
+	if !c.initDone {
+		return ErrNotInitialized
+	}
+
+	api_endpoint := endpoint{
+		operation: "do",
+		method:    strings.ToUpper(method),
+		path:      strings.TrimPrefix(path, "/"),
+		body:      bodyQuery,
+	}
+
+	switch api_endpoint.method {
+	case "POST", "PUT", "PATCH":
+		api_endpoint.body = bodyForm
+	}
+
+	return c.sendEndpoint(ctx, api_endpoint, "", form, out)
+}
+
+// callAPI: calls the wrapped API operation (see endpoints) on target,
+// e.g. the offer slug. Returns an error for an unknown operation.
+func (c *Client) callAPI(ctx context.Context, operation, target string, form url.Values, api_response interface{}) error {
+
+	api_endpoint, ok := endpoints[operation]
+	if !ok {
+		return fmt.Errorf("gomojo: unknown API operation %q", operation)
+	}
+
+	return c.sendEndpoint(ctx, api_endpoint, target, form, api_response)
+}
@@ -0,0 +1,182 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dotmanish/gomojo"
+	"github.com/dotmanish/gomojo/gomojotest"
+)
+
+func TestEndpointMethodsAndPaths(t *testing.T) {
+
+	tests := map[string]struct {
+		call     func(client *gomojo.Client) error
+		endpoint string // of the request to check
+		method   string
+		path     string
+		form     map[string]string // expected form values
+	}{
+		"auth": {
+			call: func(client *gomojo.Client) error {
+				_, err := client.GetNewAuthToken("user", "secret")
+				return err
+			},
+			endpoint: gomojotest.EndpointAuth, method: "POST", path: "/api/1/auth/",
+			form: map[string]string{"username": "user", "password": "secret"},
+		},
+		"deauth": {
+			call: func(client *gomojo.Client) error {
+				return client.DeleteAuthToken(gomojotest.DefaultAuthToken)
+			},
+			endpoint: gomojotest.EndpointDeauth, method: "DELETE", path: "/api/1/auth/" + gomojotest.DefaultAuthToken + "/",
+		},
+		"listoffers": {
+			call: func(client *gomojo.Client) error {
+				_, err := client.ListOffers()
+				return err
+			},
+			endpoint: gomojotest.EndpointListOffers, method: "GET", path: "/api/1/offer/",
+		},
+		"offerdetails": {
+			call: func(client *gomojo.Client) error {
+				_, err := client.GetOfferDetails("a")
+				return err
+			},
+			endpoint: gomojotest.EndpointOfferDetails, method: "GET", path: "/api/1/offer/a/",
+		},
+		"archiveoffer": {
+			call: func(client *gomojo.Client) error {
+				return client.ArchiveOffer("a")
+			},
+			endpoint: gomojotest.EndpointArchiveOffer, method: "DELETE", path: "/api/1/offer/a/",
+		},
+		"getfileuploadurl": {
+			call: func(client *gomojo.Client) error {
+				_, err := client.UploadReader("file.txt", strings.NewReader("content"), 7)
+				return err
+			},
+			endpoint: gomojotest.EndpointGetFileUploadURL, method: "GET", path: "/api/1/offer/get_file_upload_url/",
+		},
+		"createoffer": {
+			call: func(client *gomojo.Client) error {
+				_, err := client.CreateOffer(gomojo.Offer{Title: "New Product"})
+				return err
+			},
+			endpoint: gomojotest.EndpointCreateOffer, method: "POST", path: "/api/1/offer/",
+			form: map[string]string{"title": "New Product"},
+		},
+		"updateoffer": {
+			call: func(client *gomojo.Client) error {
+				_, err := client.UpdateOffer("a", gomojo.OfferPatch{Note: gomojo.String("Updated")})
+				return err
+			},
+			endpoint: gomojotest.EndpointUpdateOffer, method: "PATCH", path: "/api/1/offer/a/",
+			form: map[string]string{"note": "Updated"},
+		},
+		"unarchiveoffer": {
+			call: func(client *gomojo.Client) error {
+				if err := client.ArchiveOffer("a"); err != nil {
+					return err
+				}
+				_, err := client.UnarchiveOffer("a")
+				return err
+			},
+			endpoint: gomojotest.EndpointUpdateOffer, method: "PATCH", path: "/api/1/offer/a/",
+			form: map[string]string{"status": "Live"},
+		},
+	}
+
+	for _, operation := range gomojo.EndpointOperations() {
+		if _, ok := tests[operation]; !ok {
+			t.Errorf("no test for the %q endpoint", operation)
+		}
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			srv := gomojotest.NewServer()
+			defer srv.Close()
+
+			srv.AddUser("user", "secret")
+			srv.SeedOffers(gomojo.Offer{Title: "Test Product", Slug: "a", Status: "Live"})
+
+			if err := test.call(srv.NewClient()); err != nil {
+				t.Fatalf("API call failed: %v", err)
+			}
+
+			requests := srv.RequestsFor(test.endpoint)
+			if len(requests) == 0 {
+				t.Fatalf("no %s request received", test.endpoint)
+			}
+			req := requests[len(requests)-1]
+
+			if req.Method != test.method || req.Path != test.path {
+				t.Errorf("got %s %s, want %s %s", req.Method, req.Path, test.method, test.path)
+			}
+			for key, value := range test.form {
+				if got := req.Form.Get(key); got != value {
+					t.Errorf("form %s = %q, want %q", key, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestArchiveAndUnarchiveOffer(t *testing.T) {
+
+	srv := gomojotest.NewServer()
+	defer srv.Close()
+
+	srv.SeedOffers(gomojo.Offer{Title: "Test Product", Slug: "a", Status: "Live"})
+	client := srv.NewClient()
+
+	if err := client.ArchiveOffer("a"); err != nil {
+		t.Fatalf("ArchiveOffer: %v", err)
+	}
+	if req, _ := srv.LastRequest(); req.Method != "DELETE" || req.Path != "/api/1/offer/a/" {
+		t.Errorf("ArchiveOffer sent %s %s, want DELETE /api/1/offer/a/", req.Method, req.Path)
+	}
+	if offer, _ := srv.Offer("a"); offer.Status != "Archived" {
+		t.Errorf("status after ArchiveOffer = %q, want Archived", offer.Status)
+	}
+
+	offer, err := client.UnarchiveOffer("a")
+	if err != nil {
+		t.Fatalf("UnarchiveOffer: %v", err)
+	}
+	if req, _ := srv.LastRequest(); req.Method != "PATCH" || req.Path != "/api/1/offer/a/" || req.Form.Get("status") != "Live" {
+		t.Errorf("UnarchiveOffer sent %s %s %v, want PATCH /api/1/offer/a/ status=Live", req.Method, req.Path, req.Form)
+	}
+	if offer.Status != "Live" {
+		t.Errorf("status after UnarchiveOffer = %q, want Live", offer.Status)
+	}
+}
+
+func TestDoMergesFormIntoQuery(t *testing.T) {
+
+	srv := gomojotest.NewServer()
+	defer srv.Close()
+
+	form := url.Values{"b": {"2"}, "a": {"3"}}
+	if err := srv.NewClient().Do(context.Background(), "GET", "offer/?a=1", form, nil); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	req, _ := srv.LastRequest()
+	if req.Path != "/api/1/offer/" {
+		t.Errorf("got path %q, want /api/1/offer/", req.Path)
+	}
+	if want := (url.Values{"a": {"1", "3"}, "b": {"2"}}); !reflect.DeepEqual(req.Query, want) {
+		t.Errorf("got query %v, want %v", req.Query, want)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Envelope: an API response, i.e. "success" and "message" around the
+// payload field of the endpoint (e.g. "offer"), which is decoded into Data.
+// Fields of the response other than these are kept in Extra.
+// Use NewEnvelope to name the payload field, e.g. with Client.Do:
+//
+//	resp := gomojo.NewEnvelope[gomojo.Offer]("offer")
+//	err := client.Do(ctx, "GET", "offer/my-offer/", nil, resp)
+type Envelope[T any] struct {
+	Success bool
+	Message string
+	Data    T
+	Extra   map[string]json.RawMessage
+
+	field string
+}
+
+// NewEnvelope: an Envelope decoding the payload field into Data
+// field may be blank for responses without payload.
+func NewEnvelope[T any](field string) *Envelope[T] {
+	return &Envelope[T]{field: field}
+}
+
+// UnmarshalJSON: implements json.Unmarshaler
+// Field names are matched case-insensitively, like encoding/json does.
+func (e *Envelope[T]) UnmarshalJSON(data []byte) error {
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	targets := map[string]interface{}{
+		"success": &e.Success,
+		"message": &e.Message,
+	}
+	if e.field != "" {
+		targets[e.field] = &e.Data
+	}
+
+	for name, target := range targets {
+		if key, ok := fieldKey(fields, name); ok {
+			if err := json.Unmarshal(fields[key], target); err != nil {
+				return err
+			}
+			delete(fields, key)
+		}
+	}
+
+	e.Extra = nil
+	if len(fields) > 0 {
+		e.Extra = fields
+	}
+
+	return nil
+}
+
+// fieldKey: the key of fields matching the JSON field name, preferring an
+// exact match but otherwise case-insensitive, like encoding/json
+func fieldKey(fields map[string]json.RawMessage, name string) (string, bool) {
+
+	if _, ok := fields[name]; ok {
+		return name, true
+	}
+
+	for key := range fields {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+// unmarshalExtra: decodes data into the struct pointed to by v, and returns
+// the fields of data that v does not have (nil if none)
+func unmarshalExtra(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	// encoding/json matches field names case-insensitively
+	known := jsonFields(reflect.TypeOf(v).Elem())
+	for name := range fields {
+		if _, ok := known[strings.ToLower(name)]; ok {
+			delete(fields, name)
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	return fields, nil
+}
+
+// marshalExtra: encodes v, adding the extra fields it does not have
+func marshalExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for name, raw := range extra {
+		if _, ok := fields[name]; !ok {
+			fields[name] = raw
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// jsonFields: the fields of struct type t, by their (lower-cased) JSON names
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+
+	fields := make(map[string]reflect.StructField)
+
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fields[strings.ToLower(name)] = field
+	}
+
+	return fields
+}
+
+// UnmarshalJSON: implements json.Unmarshaler, keeping unknown fields in Extra
+func (o *Offer) UnmarshalJSON(data []byte) error {
+
+	// offer has the fields of Offer, but not its methods (no recursion)
+	type offer Offer
+
+	extra, err := unmarshalExtra(data, (*offer)(o))
+	if err != nil {
+		return err
+	}
+	o.Extra = extra
+
+	return nil
+}
+
+// MarshalJSON: implements json.Marshaler, including the fields in Extra
+func (o Offer) MarshalJSON() ([]byte, error) {
+
+	type offer Offer
+
+	return marshalExtra(offer(o), o.Extra)
+}
+
+// UnmarshalJSON: implements json.Unmarshaler, keeping unknown fields in Extra
+func (r *UploadResult) UnmarshalJSON(data []byte) error {
+
+	type uploadResult UploadResult
+
+	extra, err := unmarshalExtra(data, (*uploadResult)(r))
+	if err != nil {
+		return err
+	}
+	r.Extra = extra
+
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/dotmanish/gomojo"
+)
+
+func TestEnvelopeUnmarshalJSON(t *testing.T) {
+
+	tests := map[string]struct {
+		field   string
+		body    string
+		success bool
+		message string
+		title   string
+		extra   map[string]json.RawMessage
+	}{
+		"payload": {
+			field: "offer", body: `{"success": true, "offer": {"title": "A"}}`,
+			success: true, title: "A",
+		},
+		"payload and extra fields": {
+			field: "offer", body: `{"success": true, "message": "Found.", "offer": {"title": "A"}, "request_id": "abc", "meta": {"page": 1}}`,
+			success: true, message: "Found.", title: "A",
+			extra: map[string]json.RawMessage{"request_id": json.RawMessage(`"abc"`), "meta": json.RawMessage(`{"page": 1}`)},
+		},
+		"other case": {
+			field: "offer", body: `{"Success": true, "MESSAGE": "Found.", "Offer": {"Title": "A"}}`,
+			success: true, message: "Found.", title: "A",
+		},
+		"no payload field": {
+			field: "", body: `{"success": false, "message": "Failed.", "offer": {"title": "A"}}`,
+			message: "Failed.",
+			extra:   map[string]json.RawMessage{"offer": json.RawMessage(`{"title": "A"}`)},
+		},
+		"missing payload": {
+			field: "offer", body: `{"success": false, "message": "Failed."}`,
+			message: "Failed.",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			envelope := gomojo.NewEnvelope[gomojo.Offer](test.field)
+			if err := json.Unmarshal([]byte(test.body), envelope); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+
+			if envelope.Success != test.success || envelope.Message != test.message || envelope.Data.Title != test.title {
+				t.Errorf("got success %v, message %q, title %q, want %v, %q, %q",
+					envelope.Success, envelope.Message, envelope.Data.Title, test.success, test.message, test.title)
+			}
+
+			if !reflect.DeepEqual(envelope.Extra, test.extra) {
+				t.Errorf("got Extra %s, want %s", envelope.Extra, test.extra)
+			}
+		})
+	}
+}
+
+func TestEnvelopeUnmarshalJSONErrors(t *testing.T) {
+
+	for _, body := range []string{`[]`, `{"success": "yes"}`, `{"success": true, "offer": "A"}`} {
+		if err := json.Unmarshal([]byte(body), gomojo.NewEnvelope[gomojo.Offer]("offer")); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want an error", body)
+		}
+	}
+}
+
+func TestEnvelopeFieldCaseHasNoDrift(t *testing.T) {
+
+	body := mustJSON(t, map[string]interface{}{"Success": true, "Offer": offerJSON(map[string]interface{}{"title": removed, "Title": "A"})})
+
+	drifts, err := gomojo.DetectDrift("offerdetails", body)
+	if err != nil || len(drifts) != 0 {
+		t.Errorf("DetectDrift: got %v, %v, want no drift", drifts, err)
+	}
+}
+
+func TestOfferExtraRoundTrip(t *testing.T) {
+
+	body := `{"title": "A", "slug": "a", "base_price": "10.00", "tags": ["new", "sale"], "rating": {"stars": 4.5}, "Status": "Live"}`
+
+	var offer gomojo.Offer
+	if err := json.Unmarshal([]byte(body), &offer); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if offer.Title != "A" || offer.Slug != "a" || offer.BasePrice != "10.00" || offer.Status != "Live" {
+		t.Errorf("got %+v, want the known fields decoded", offer)
+	}
+
+	want_extra := map[string]json.RawMessage{
+		"tags":   json.RawMessage(`["new", "sale"]`),
+		"rating": json.RawMessage(`{"stars": 4.5}`),
+	}
+	if !reflect.DeepEqual(offer.Extra, want_extra) {
+		t.Errorf("got Extra %s, want %s", offer.Extra, want_extra)
+	}
+
+	encoded, err := json.Marshal(offer)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var fields map[string]interface{}
+	if err = json.Unmarshal(encoded, &fields); err != nil {
+		t.Fatalf("Unmarshal of %s: %v", encoded, err)
+	}
+	if fields["title"] != "A" || fields["status"] != "Live" || fields["tags"] == nil || fields["rating"] == nil {
+		t.Errorf("Marshal: got %s, want the known and the extra fields", encoded)
+	}
+
+	var decoded gomojo.Offer
+	if err = json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unmarshal of %s: %v", encoded, err)
+	}
+	if decoded.Title != offer.Title || decoded.Status != offer.Status || len(decoded.Extra) != len(offer.Extra) {
+		t.Errorf("round trip: got %+v, want %+v", decoded, offer)
+	}
+}
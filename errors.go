@@ -0,0 +1,172 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Sentinel errors, for use with errors.Is
+var (
+	// ErrNotInitialized: the Client lacks App ID, API version or credentials
+	ErrNotInitialized = errors.New("gomojo: client not initialized with App ID, API version and Auth Token or Username/Password")
+
+	// ErrBadRequest: the API rejected the request's parameters (HTTP 400)
+	ErrBadRequest = errors.New("gomojo: bad request")
+
+	// ErrUnauthorized: the API rejected the App ID or Auth Token (HTTP 401)
+	ErrUnauthorized = errors.New("gomojo: unauthorized")
+
+	// ErrForbidden: the Auth Token may not perform the request (HTTP 403)
+	ErrForbidden = errors.New("gomojo: forbidden")
+
+	// ErrNotFound: the requested resource (e.g. Offer) does not exist (HTTP 404)
+	ErrNotFound = errors.New("gomojo: not found")
+
+	// ErrRateLimited: too many requests were sent to the API (HTTP 429)
+	ErrRateLimited = errors.New("gomojo: rate limited")
+
+	// ErrServerError: the API (or a proxy in front of it) failed (HTTP 5xx)
+	ErrServerError = errors.New("gomojo: server error")
+
+	// ErrTransport: the API could not be reached or did not respond
+	ErrTransport = errors.New("gomojo: transport error")
+
+	// ErrInvalidResponse: a successful HTTP response was not the expected JSON
+	// (wrong Content-Type or malformed body)
+	ErrInvalidResponse = errors.New("gomojo: invalid response")
+
+	// ErrTruncatedResponse: the response body ended before its Content-Length
+	// (or the connection broke while reading it)
+	ErrTruncatedResponse = errors.New("gomojo: truncated response")
+
+	// ErrSchemaDrift: in strict mode, the response has fields that gomojo
+	// does not know, lacks expected ones or has retyped ones (see DriftError)
+	ErrSchemaDrift = errors.New("gomojo: schema drift")
+
+	// ErrUploadFailed: the file could not be read or sent to the upload URL,
+	// or the upload server rejected it (after get_file_upload_url succeeded)
+	ErrUploadFailed = errors.New("gomojo: upload failed")
+)
+
+// snippetLength: how much of a non-JSON response body APIError shows
+const snippetLength = 200
+
+// APIError: represents an unsuccessful API response
+// Use errors.As to retrieve it from an error returned by the Client,
+// and errors.Is to compare it with ErrBadRequest, ErrUnauthorized,
+// ErrForbidden, ErrNotFound, ErrRateLimited or ErrServerError (by status code),
+// or with ErrInvalidResponse or ErrTruncatedResponse (by Cause).
+type APIError struct {
+	StatusCode int    // HTTP status code of the response
+	Method     string // HTTP method of the request
+	Endpoint   string // API endpoint, e.g. "offer/my-offer-slug/"
+	Message    string // Message returned by Instamojo (or describing the bad response)
+	Body       []byte // Raw response body
+	Cause      error  // Why the response could not be decoded (nil if it could)
+}
+
+// Error: implements the error interface
+// A body that is not JSON (e.g. the HTML error page of a proxy) is quoted,
+// shortened to its first few hundred bytes.
+func (e *APIError) Error() string {
+
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+
+	if len(e.Body) > 0 && !json.Valid(e.Body) {
+		message += fmt.Sprintf(" (body: %q)", e.Snippet())
+	}
+
+	return fmt.Sprintf("gomojo: %s %s: HTTP %d: %s", e.Method, e.Endpoint, e.StatusCode, message)
+}
+
+// Snippet: the start of the raw response body, for diagnostics
+func (e *APIError) Snippet() string {
+
+	snippet := bytes.TrimSpace(e.Body)
+	if len(snippet) > snippetLength {
+		return string(snippet[:snippetLength]) + "..."
+	}
+
+	return string(snippet)
+}
+
+// Is: matches the sentinel errors of the HTTP status code
+func (e *APIError) Is(target error) bool {
+
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServerError:
+		return e.StatusCode >= 500 && e.StatusCode <= 599
+	}
+
+	return false
+}
+
+// Unwrap: the Cause, e.g. ErrInvalidResponse or ErrTruncatedResponse
+func (e *APIError) Unwrap() error {
+	return e.Cause
+}
+
+// transportError: wraps err (from building/sending a request) with ErrTransport
+// The endpoint, and the URL in a *url.Error from the http.Client, are
+// redacted (see redactEndpoint), as the error text is likely to be logged.
+func transportError(method, endpoint string, err error) error {
+
+	if url_err, ok := err.(*url.Error); ok {
+		redacted_err := *url_err
+		redacted_err.URL = redactEndpoint(url_err.URL)
+		err = &redacted_err
+	}
+
+	return fmt.Errorf("%w: %s %s: %w", ErrTransport, method, redactEndpoint(endpoint), err)
+}
+
+// uploadError: wraps err (from reading or sending the file) with ErrUploadFailed
+func uploadError(err error) error {
+	return fmt.Errorf("%w: %w", ErrUploadFailed, err)
+}
+
+// msgNotInitialized: the message the package-level functions have always
+// returned when called before InitGomojoWithAuthToken/InitGomojoWithUserPass
+const msgNotInitialized = "Please call gomojo.InitGomojoWithAuthToken() or gomojo.InitGomojoWithUserPass() first."
+
+// errorMessage: returns the (bool, string) pair the package-level functions
+// have always returned, for the given error
+func errorMessage(err error) (bool, string) {
+
+	if err == nil {
+		return true, ""
+	}
+
+	if errors.Is(err, ErrNotInitialized) {
+		return false, msgNotInitialized
+	}
+
+	var api_err *APIError
+	if errors.As(err, &api_err) && api_err.Message != "" {
+		return false, api_err.Message
+	}
+
+	return false, err.Error()
+}
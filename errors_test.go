@@ -0,0 +1,108 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dotmanish/gomojo"
+	"github.com/dotmanish/gomojo/gomojotest"
+)
+
+func TestResponseErrors(t *testing.T) {
+
+	long_page := "<html><body>" + strings.Repeat("Service temporarily unavailable. ", 50) + "</body></html>"
+
+	tests := map[string]struct {
+		fault       gomojotest.Fault
+		sentinels   []error // the error matches
+		status_code int
+		message     string // of the *APIError
+		snippet     string // start of the *APIError's Snippet
+	}{
+		"non-2xx with JSON message": {
+			fault:       gomojotest.Fault{StatusCode: 404, Message: "Offer not found."},
+			sentinels:   []error{gomojo.ErrNotFound},
+			status_code: 404, message: "Offer not found.",
+		},
+		"non-2xx with HTML": {
+			fault:       gomojotest.Fault{StatusCode: 502, Body: "<html><body>Bad Gateway</body></html>"},
+			sentinels:   []error{gomojo.ErrServerError},
+			status_code: 502, snippet: "<html><body>Bad Gateway</body></html>",
+		},
+		"non-2xx with long HTML": {
+			fault:       gomojotest.Fault{StatusCode: 503, Body: long_page},
+			sentinels:   []error{gomojo.ErrServerError},
+			status_code: 503, snippet: long_page[:200] + "...",
+		},
+		"2xx with wrong Content-Type": {
+			fault:       gomojotest.Fault{StatusCode: 200, Body: `{"success": true, "offer": {}}`, ContentType: "text/html"},
+			sentinels:   []error{gomojo.ErrInvalidResponse},
+			status_code: 200, message: "Unexpected Content-Type text/html",
+		},
+		"2xx with malformed JSON": {
+			fault:       gomojotest.Fault{StatusCode: 200, Body: `{"success": true, "offer": `, ContentType: "application/json"},
+			sentinels:   []error{gomojo.ErrInvalidResponse},
+			status_code: 200,
+		},
+		"2xx truncated": {
+			fault:       gomojotest.Fault{StatusCode: 200, Body: `{"success": true, "offer": {"title": "A"}}`, Truncate: true},
+			sentinels:   []error{gomojo.ErrTruncatedResponse},
+			status_code: 200,
+		},
+		"2xx with success false": {
+			fault:       gomojotest.Fault{StatusCode: 200, Body: `{"success": false, "message": "Offer is archived."}`},
+			status_code: 200, message: "Offer is archived.",
+		},
+	}
+
+	all_sentinels := []error{gomojo.ErrNotFound, gomojo.ErrServerError, gomojo.ErrInvalidResponse, gomojo.ErrTruncatedResponse}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			srv := gomojotest.NewServer()
+			defer srv.Close()
+
+			srv.SeedOffers(gomojo.Offer{Title: "A", Slug: "a"})
+			srv.SetFault(gomojotest.EndpointOfferDetails, test.fault)
+
+			_, err := srv.NewClient().GetOfferDetails("a")
+
+			var api_err *gomojo.APIError
+			if !errors.As(err, &api_err) {
+				t.Fatalf("got %v, want an *APIError", err)
+			}
+
+			for _, sentinel := range all_sentinels {
+				want := false
+				for _, expected := range test.sentinels {
+					want = want || expected == sentinel
+				}
+				if got := errors.Is(err, sentinel); got != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", err, sentinel, got, want)
+				}
+			}
+
+			if api_err.StatusCode != test.status_code {
+				t.Errorf("StatusCode = %d, want %d", api_err.StatusCode, test.status_code)
+			}
+			if test.message != "" && api_err.Message != test.message {
+				t.Errorf("Message = %q, want %q", api_err.Message, test.message)
+			}
+			if test.snippet != "" {
+				if api_err.Snippet() != test.snippet {
+					t.Errorf("Snippet() = %q, want %q", api_err.Snippet(), test.snippet)
+				}
+				if !strings.Contains(err.Error(), "(body: ") {
+					t.Errorf("error text %q does not quote the body", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+// EndpointOperations: the operations of the endpoint table, for the tests
+// of package gomojo_test
+func EndpointOperations() []string {
+
+	operations := make([]string, 0, len(endpoints))
+	for operation := range endpoints {
+		operations = append(operations, operation)
+	}
+
+	return operations
+}
@@ -0,0 +1,62 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// formContentType: Content-Type of all API request bodies
+const formContentType = "application/x-www-form-urlencoded"
+
+// AuthRequest: represents the form body of 'auth' POST API
+type AuthRequest struct {
+	Username string `form:"username"`
+	Password string `form:"password"`
+}
+
+// encodeForm: encodes the `form`-tagged fields of a struct (or pointer to it)
+// as url.Values. Fields tagged `form:"-"` or without a tag are skipped, and
+// nil pointer fields are left out, so that they are not sent at all.
+func encodeForm(v interface{}) url.Values {
+
+	form := make(url.Values)
+
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return form
+	}
+
+	value_type := value.Type()
+
+	for i := 0; i < value_type.NumField(); i++ {
+
+		field := value_type.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		field_value := value.Field(i)
+		if field_value.Kind() == reflect.Ptr {
+			if field_value.IsNil() {
+				continue
+			}
+			field_value = field_value.Elem()
+		}
+
+		if field_value.Kind() == reflect.String {
+			form.Set(name, field_value.String())
+		} else {
+			form.Set(name, fmt.Sprint(field_value.Interface()))
+		}
+	}
+
+	return form
+}
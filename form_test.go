@@ -0,0 +1,62 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"testing"
+
+	"github.com/dotmanish/gomojo"
+	"github.com/dotmanish/gomojo/gomojotest"
+)
+
+func TestFormEncodesSpecialCharacters(t *testing.T) {
+
+	const password = "p&a+ss=w%"
+	const title = "Tea & Biscuits + Café ☕"
+
+	srv := gomojotest.NewServer()
+	defer srv.Close()
+
+	srv.AddUser("user@example.com", password)
+	client := srv.NewClient()
+
+	if _, err := client.GetNewAuthToken("user@example.com", password); err != nil {
+		t.Fatalf("GetNewAuthToken: %v", err)
+	}
+
+	req, _ := srv.LastRequest()
+	if got := req.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded" {
+		t.Errorf("auth Content-Type = %q", got)
+	}
+	if got := req.Form.Get("username"); got != "user@example.com" {
+		t.Errorf("auth username = %q", got)
+	}
+	if got := req.Form.Get("password"); got != password {
+		t.Errorf("auth password = %q, want %q", got, password)
+	}
+
+	offer, err := client.CreateOffer(gomojo.Offer{Title: title, Note: "a=b&c=d"})
+	if err != nil {
+		t.Fatalf("CreateOffer: %v", err)
+	}
+	if offer.Title != title {
+		t.Errorf("created offer title = %q, want %q", offer.Title, title)
+	}
+
+	req, _ = srv.LastRequest()
+	if got := req.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded" {
+		t.Errorf("createoffer Content-Type = %q", got)
+	}
+	if got := req.Form.Get("title"); got != title {
+		t.Errorf("createoffer title = %q, want %q", got, title)
+	}
+	if got := req.Form.Get("note"); got != "a=b&c=d" {
+		t.Errorf("createoffer note = %q", got)
+	}
+	if _, ok := req.Form["shorturl"]; ok {
+		t.Error("createoffer sent the read-only shorturl field")
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+// Recording of API responses (-record) and the 'drift' action
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dotmanish/gomojo"
+)
+
+// recordedResponse: one line of the -record file
+// Only the operation is kept of the request, as the endpoint may hold
+// the Auth Token (deauth) or the upload URL's signature.
+type recordedResponse struct {
+	Operation  string          `json:"operation"`
+	StatusCode int             `json:"status"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// recordResponses: an Interceptor appending every JSON API response to the -record file
+// The responses of 'auth' are not recorded, as they hold the Auth Token, and
+// the signature of the upload_url in those of 'getfileuploadurl' is redacted.
+func recordResponses(next gomojo.Handler) gomojo.Handler {
+	return func(call *gomojo.Call) error {
+
+		err := next(call)
+
+		if call.Operation == "auth" || call.Response == nil || !json.Valid(call.ResponseBody) {
+			return err
+		}
+
+		body := json.RawMessage(call.ResponseBody)
+		if call.Operation == "getfileuploadurl" {
+			if body = redactUploadURL(body); body == nil {
+				return err
+			}
+		}
+
+		line, _ := json.Marshal(recordedResponse{
+			Operation:  call.Operation,
+			StatusCode: call.Response.StatusCode,
+			Body:       body,
+		})
+
+		file, open_err := os.OpenFile(cmd_record_file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if open_err != nil {
+			fmt.Println("Unable to record the API response:", open_err)
+			return err
+		}
+		defer file.Close()
+
+		if _, write_err := file.Write(append(line, '\n')); write_err != nil {
+			fmt.Println("Unable to record the API response:", write_err)
+		}
+
+		return err
+	}
+}
+
+// redactUploadURL: the getfileuploadurl response body with the query string
+// values (expiry, signature) of its upload_url redacted
+// Returns nil if the body cannot be redacted, so it is not recorded at all.
+func redactUploadURL(body json.RawMessage) json.RawMessage {
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return nil
+	}
+
+	for name, value := range fields {
+		if !strings.EqualFold(name, "upload_url") || string(value) == "null" {
+			continue
+		}
+
+		var upload_url string
+		if json.Unmarshal(value, &upload_url) != nil {
+			return nil
+		}
+
+		parsed_url, err := url.Parse(upload_url)
+		if err != nil {
+			return nil
+		}
+
+		query := parsed_url.Query()
+		for key := range query {
+			query[key] = []string{"REDACTED"}
+		}
+		parsed_url.RawQuery = query.Encode()
+
+		fields[name], _ = json.Marshal(parsed_url.String())
+	}
+
+	redacted, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+
+	return redacted
+}
+
+// operationDrift: the drift seen in the recorded responses of one operation
+type operationDrift struct {
+	responses   int
+	drifted     int
+	occurrences map[gomojo.Drift]int
+}
+
+// summariseDrift: shows the drift seen in the successful responses recorded in record_file
+func summariseDrift(record_file string) {
+
+	file, err := os.Open(record_file)
+	if err != nil {
+		fmt.Println("Unable to read the recorded API responses:", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	operations := make(map[string]*operationDrift)
+	total := 0
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+
+		var recorded recordedResponse
+		if json.Unmarshal(scanner.Bytes(), &recorded) != nil || recorded.StatusCode < 200 || recorded.StatusCode > 299 {
+			continue
+		}
+
+		drifts, drift_err := gomojo.DetectDrift(recorded.Operation, recorded.Body)
+		if drift_err != nil {
+			continue
+		}
+
+		total++
+
+		operation := operations[recorded.Operation]
+		if operation == nil {
+			operation = &operationDrift{occurrences: make(map[gomojo.Drift]int)}
+			operations[recorded.Operation] = operation
+		}
+
+		operation.responses++
+		if len(drifts) > 0 {
+			operation.drifted++
+		}
+		for _, drift := range drifts {
+			operation.occurrences[drift]++
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		fmt.Println("Unable to read the recorded API responses:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Drift in %d recorded successful API responses (%s)\n", total, record_file)
+	fmt.Println("----------------------------")
+
+	names := make([]string, 0, len(operations))
+	for name := range operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+
+		operation := operations[name]
+		fmt.Printf("%s: %d responses, %d with drift\n", name, operation.responses, operation.drifted)
+
+		drifts := make([]gomojo.Drift, 0, len(operation.occurrences))
+		for drift := range operation.occurrences {
+			drifts = append(drifts, drift)
+		}
+		sort.Slice(drifts, func(i, j int) bool {
+			return drifts[i].String() < drifts[j].String()
+		})
+
+		for _, drift := range drifts {
+			fmt.Printf("    %dx %s\n", operation.occurrences[drift], drift)
+		}
+	}
+	fmt.Println("----------------------------")
+}
@@ -11,7 +11,7 @@
 //
 // Currently Available actions:
 //
-// auth, deauth, listoffers, offerdetails, archiveoffer
+// auth, deauth, listoffers, offerdetails, archiveoffer, unarchiveoffer, upload, drift
 //
 // Example usage of the command-line API tool:
 //
@@ -21,6 +21,15 @@
 //
 // gomojo-tool -action archiveoffer -offerslug <offer slug> -app <your App-ID> -token <auth token>
 //
+// gomojo-tool -action unarchiveoffer -offerslug <offer slug> -app <your App-ID> -token <auth token>
+//
+// gomojo-tool -action upload -file <file path> -app <your App-ID> -token <auth token>
+//
+// API responses can be recorded with -record <file> (with any action), and the
+// fields Instamojo added, removed or retyped in them summarised later:
+//
+// gomojo-tool -action drift -record <file>
+//
 // If you don't have a pre-generated Auth Token, you can either generate one first like this
 //
 // gomojo-tool -action auth -app <your App-ID> -user <your username> -passwd <your password>
@@ -35,17 +44,34 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
+	"time"
+
+	// Offer Timezones are checked with time.LoadLocation: embed the time
+	// zone database for hosts without zoneinfo (e.g. minimal containers)
+	_ "time/tzdata"
 
 	"github.com/dotmanish/gomojo"
 )
 
 var cmd_action, cmd_app_id, cmd_auth_token, cmd_username, cmd_passwd, cmd_api_ver string
-var cmd_offer_slug string
+var cmd_offer_slug, cmd_base_url, cmd_file string
+var cmd_token_file, cmd_token_pass, cmd_record_file string
+var cmd_sandbox, cmd_verbose, cmd_strict bool
+var cmd_timeout time.Duration
 var authenticated_in_current bool
 
+// mojo_client: the gomojo Client used for all API calls of this invocation
+var mojo_client *gomojo.Client
+
+// token_store: where Auth Tokens are kept across invocations (-tokenfile)
+var token_store gomojo.TokenStore
+
 // Note to people who may read this for learning:
 // There are multiple sophisticated command-line option parsers available for Go
 // at http://code.google.com/p/go-wiki/wiki/Projects#Command-line_Option_Parsers
@@ -59,7 +85,16 @@ func init() {
 	flag.StringVar(&cmd_username, "user", "", "Username (for Auth)")
 	flag.StringVar(&cmd_passwd, "passwd", "", "Password (for Auth)")
 	flag.StringVar(&cmd_offer_slug, "offerslug", "", "Offer Slug")
+	flag.StringVar(&cmd_file, "file", "", "File to upload")
 	flag.StringVar(&cmd_api_ver, "version", "1", "API Version (default 1)")
+	flag.StringVar(&cmd_base_url, "baseurl", "", "API Base URL (default "+gomojo.ProductionBaseURL+")")
+	flag.BoolVar(&cmd_sandbox, "sandbox", false, "Use the Instamojo test environment ("+gomojo.SandboxBaseURL+")")
+	flag.BoolVar(&cmd_verbose, "verbose", false, "Log every API request (secrets redacted) to stderr")
+	flag.DurationVar(&cmd_timeout, "timeout", 0, "Time limit for each API request, e.g. 30s (default none)")
+	flag.StringVar(&cmd_token_file, "tokenfile", "", "File to keep the Auth Token in across invocations")
+	flag.StringVar(&cmd_token_pass, "tokenpass", "", "Passphrase to encrypt the -tokenfile with (or set GOMOJO_TOKEN_PASSPHRASE)")
+	flag.StringVar(&cmd_record_file, "record", "", "File to append the API responses to (read by the 'drift' action)")
+	flag.BoolVar(&cmd_strict, "strict", false, "Fail API calls whose response has unknown, missing or retyped fields")
 
 }
 
@@ -73,14 +108,22 @@ func initParams() {
 
 	flag.Parse()
 
-	if cmd_action != "auth" && cmd_action != "deauth" && cmd_action != "listoffers" && cmd_action != "offerdetails" && cmd_action != "archiveoffer" {
-		fmt.Print("You must specify the action on command line: 'auth', 'deauth', 'listoffers', 'offerdetails', 'archiveoffer'\n\n")
+	if cmd_action != "auth" && cmd_action != "deauth" && cmd_action != "listoffers" && cmd_action != "offerdetails" && cmd_action != "archiveoffer" && cmd_action != "unarchiveoffer" && cmd_action != "upload" && cmd_action != "drift" {
+		fmt.Print("You must specify the action on command line: 'auth', 'deauth', 'listoffers', 'offerdetails', 'archiveoffer', 'unarchiveoffer', 'upload', 'drift'\n\n")
 		paramsOkay = false
+	} else if cmd_action == "drift" {
+		if cmd_record_file == "" {
+			fmt.Print("You must specifiy the recorded API responses via the command line option -record to summarise drift.\n\n")
+			paramsOkay = false
+		}
 	} else if cmd_app_id == "" {
 		fmt.Print("You must specify the App-ID from command line via the '-app' parameter.\n\n")
 		paramsOkay = false
-	} else if cmd_auth_token == "" && (cmd_username == "" || cmd_passwd == "") {
-		fmt.Print("If 'token' is not supplied, then both 'user' and 'password' parameters must be supplied from command line.\n\n")
+	} else if cmd_auth_token == "" && cmd_token_file == "" && (cmd_username == "" || cmd_passwd == "") {
+		fmt.Print("If 'token' or 'tokenfile' is not supplied, then both 'user' and 'password' parameters must be supplied from command line.\n\n")
+		paramsOkay = false
+	} else if cmd_action == "auth" && (cmd_username == "" || cmd_passwd == "") {
+		fmt.Print("Both 'user' and 'password' parameters must be supplied from command line to get a new Auth Token.\n\n")
 		paramsOkay = false
 	} else if cmd_action == "offerdetails" && cmd_offer_slug == "" {
 		fmt.Print("You must specifiy the Offer Slug via the command line option -offerslug to get offer details.\n\n")
@@ -88,19 +131,41 @@ func initParams() {
 	} else if cmd_action == "archiveoffer" && cmd_offer_slug == "" {
 		fmt.Print("You must specifiy the Offer Slug via the command line option -offerslug to archive the offer.\n\n")
 		paramsOkay = false
+	} else if cmd_action == "unarchiveoffer" && cmd_offer_slug == "" {
+		fmt.Print("You must specifiy the Offer Slug via the command line option -offerslug to unarchive the offer.\n\n")
+		paramsOkay = false
+	} else if cmd_action == "upload" && cmd_file == "" {
+		fmt.Print("You must specifiy the file to upload via the command line option -file.\n\n")
+		paramsOkay = false
 	}
 
 	if !paramsOkay {
 		fmt.Printf("* gomojo v %s from https://github.com/dotmanish/gomojo\n\n", gomojo_version)
-		fmt.Print("Usage: gomojo-tool -action <Action> -app <App IP> [-token <Auth Token>] [-user <Username>] [-passwd <Password>] [-offer offer-slug]\n\n")
-		fmt.Print("Currently Available actions: auth, deauth, listoffers, offerdetails, archiveoffer\n")
+		fmt.Print("Usage: gomojo-tool -action <Action> -app <App IP> [-token <Auth Token>] [-user <Username>] [-passwd <Password>] [-offer offer-slug] [-file <file path>] [-sandbox | -baseurl <API Base URL>] [-tokenfile <file> [-tokenpass <passphrase>]] [-record <file>] [-strict]\n\n")
+		fmt.Print("Currently Available actions: auth, deauth, listoffers, offerdetails, archiveoffer, unarchiveoffer, upload, drift\n")
 		fmt.Print("Example: gomojo-tool -action listoffers -app <your App-ID> -token <auth token>\n")
 		fmt.Print("Example: gomojo-tool -action listoffers -app <your App-ID> -user <your username> -passwd <your password>\n")
+		fmt.Print("Example: gomojo-tool -action listoffers -app <your App-ID> -user <your username> -passwd <your password> -tokenfile ~/.gomojo-token\n")
 		os.Exit(1)
 	}
 
 }
 
+// printAPIError: shows the Instamojo message (or error text) of a failed API call
+func printAPIError(api_name string, err error) {
+
+	if err == nil {
+		return
+	}
+
+	var api_err *gomojo.APIError
+	if errors.As(err, &api_err) {
+		fmt.Printf("%s API Message: %s (HTTP %d)\n", api_name, api_err.Message, api_err.StatusCode)
+	} else {
+		fmt.Printf("%s API Error: %s\n", api_name, err)
+	}
+}
+
 // processCommandLineAPI: Main handler for command-line usage
 // The responsibility of this function is to make sure that
 // we display on-screen what's happening and show the API responses
@@ -108,10 +173,10 @@ func processCommandLineAPI(apicall string) {
 
 	if apicall == "listoffers" {
 
-		offers, list_success, list_message := gomojo.ListOffers()
+		offers, list_err := mojo_client.ListOffers()
 
-		fmt.Println("List Offers API Success:", list_success)
-		fmt.Println("List Offers API Message:", list_message)
+		fmt.Println("List Offers API Success:", list_err == nil)
+		printAPIError("List Offers", list_err)
 		fmt.Printf("Total %d Offers\n", len(offers))
 		fmt.Println("----------------------------")
 
@@ -127,12 +192,12 @@ func processCommandLineAPI(apicall string) {
 
 	} else if apicall == "offerdetails" {
 
-		offer, details_success, details_message := gomojo.GetOfferDetails(cmd_offer_slug)
+		offer, details_err := mojo_client.GetOfferDetails(cmd_offer_slug)
 
-		fmt.Println("Offer Details API Success:", details_success)
-		fmt.Println("Offer Details API Message:", details_message)
+		fmt.Println("Offer Details API Success:", details_err == nil)
+		printAPIError("Offer Details", details_err)
 
-		if details_success {
+		if details_err == nil {
 			fmt.Println("----------------------------")
 			fmt.Println("Status:", offer.Status)
 			fmt.Println("Title:", offer.Title)
@@ -153,40 +218,157 @@ func processCommandLineAPI(apicall string) {
 
 	} else if apicall == "auth" {
 
-		auth_token, auth_success, auth_message := gomojo.GetNewAuthToken(cmd_username, cmd_passwd)
+		auth_token, auth_err := mojo_client.GetNewAuthToken(cmd_username, cmd_passwd)
 
 		fmt.Println("New Auth Token:", auth_token)
-		fmt.Println("Auth API Success:", auth_success)
-		fmt.Println("Auth API Message:", auth_message)
+		fmt.Println("Auth API Success:", auth_err == nil)
+		printAPIError("Auth", auth_err)
+
+		mojo_client.SetCurrentAuthToken(auth_token)
 
-		gomojo.SetCurrentAuthToken(auth_token)
+		if auth_err == nil && token_store != nil {
+			if save_err := token_store.SaveToken(cmd_app_id, auth_token); save_err != nil {
+				fmt.Println("Unable to save the Auth Token:", save_err)
+			} else {
+				fmt.Println("Saved the Auth Token to", cmd_token_file)
+			}
+		}
 
 	} else if apicall == "deauth" {
 
-		deauth_success, deauth_message := gomojo.DeleteAuthToken(cmd_auth_token)
+		if cmd_auth_token == "" && token_store != nil {
+			cmd_auth_token, _ = token_store.LoadToken(cmd_app_id)
+		}
+
+		deauth_err := mojo_client.DeleteAuthToken(cmd_auth_token)
+
+		fmt.Println("Delete-Auth API Success:", deauth_err == nil)
+		printAPIError("Delete-Auth", deauth_err)
 
-		fmt.Println("Delete-Auth API Success:", deauth_success)
-		fmt.Println("Delete-Auth API Message:", deauth_message)
-	
 	} else if apicall == "archiveoffer" {
 
-		archive_success, archive_message := gomojo.ArchiveOffer(cmd_offer_slug)
+		archive_err := mojo_client.ArchiveOffer(cmd_offer_slug)
+
+		fmt.Println("Archive-Offer API Success:", archive_err == nil)
+		printAPIError("Archive-Offer", archive_err)
+
+	} else if apicall == "unarchiveoffer" {
+
+		offer, unarchive_err := mojo_client.UnarchiveOffer(cmd_offer_slug)
 
-		fmt.Println("Archive-Offer API Success:", archive_success)
-		fmt.Println("Archive-Offer API Message:", archive_message)
+		fmt.Println("Unarchive-Offer API Success:", unarchive_err == nil)
+		printAPIError("Unarchive-Offer", unarchive_err)
+
+		if unarchive_err == nil {
+			fmt.Println("Status:", offer.Status)
+		}
+
+	} else if apicall == "upload" {
+
+		result, upload_err := mojo_client.UploadFile(cmd_file)
+		fmt.Fprintln(os.Stderr)
+
+		fmt.Println("Upload API Success:", upload_err == nil)
+		printAPIError("Upload", upload_err)
+
+		if upload_err == nil {
+			fmt.Println("----------------------------")
+			fmt.Println("File ID:", result.FileID)
+			fmt.Println("File Name:", result.FileName)
+			fmt.Println("Size:", result.Size)
+			fmt.Println("Upload JSON:", result.JSON)
+			fmt.Println("----------------------------")
+		}
 	}
 
 }
 
+// printProgress: renders a progress bar of the upload on stderr
+func printProgress(name string, sent, total int64) {
+
+	const width = 40
+
+	if total <= 0 {
+		fmt.Fprintf(os.Stderr, "\rUploading %s: %d bytes", name, sent)
+		return
+	}
+
+	done := int(sent * width / total)
+	if done > width {
+		done = width
+	}
+
+	fmt.Fprintf(os.Stderr, "\rUploading %s: [%s%s] %3d%% (%d/%d bytes)", name,
+		strings.Repeat("#", done), strings.Repeat(".", width-done), sent*100/total, sent, total)
+}
+
 func main() {
 
 	initParams()
 
+	if cmd_action == "drift" {
+		summariseDrift(cmd_record_file)
+		return
+	}
+
+	client_opts := []gomojo.ClientOption{
+		gomojo.WithAPIVersion(cmd_api_ver),
+		gomojo.WithRetryPolicy(gomojo.DefaultRetryPolicy()),
+	}
+
+	if cmd_verbose {
+		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		client_opts = append(client_opts, gomojo.WithLogger(logger))
+	}
+
+	if cmd_action == "upload" {
+		client_opts = append(client_opts, gomojo.WithUploadProgress(printProgress))
+	}
+
+	if cmd_record_file != "" {
+		client_opts = append(client_opts, gomojo.WithInterceptors(recordResponses))
+	}
+
+	if cmd_strict {
+		client_opts = append(client_opts, gomojo.WithStrictDecoding())
+	}
+
+	if cmd_timeout > 0 {
+		client_opts = append(client_opts, gomojo.WithTimeout(cmd_timeout))
+	}
+
+	if cmd_sandbox {
+		client_opts = append(client_opts, gomojo.WithBaseURL(gomojo.SandboxBaseURL))
+	} else if cmd_base_url != "" {
+		client_opts = append(client_opts, gomojo.WithBaseURL(cmd_base_url))
+	}
+
+	if cmd_token_file != "" {
+		if cmd_token_pass == "" {
+			cmd_token_pass = os.Getenv("GOMOJO_TOKEN_PASSPHRASE")
+		}
+
+		if cmd_token_pass != "" {
+			token_store = gomojo.NewEncryptedFileTokenStore(cmd_token_file, cmd_token_pass)
+		} else {
+			token_store = gomojo.NewFileTokenStore(cmd_token_file)
+		}
+
+		client_opts = append(client_opts, gomojo.WithTokenStore(token_store))
+	}
+
 	// Decide how to initialize gomojo
 	if cmd_auth_token != "" {
-		gomojo.InitGomojoWithAuthToken(cmd_api_ver, cmd_app_id, cmd_auth_token)
+		mojo_client = gomojo.NewClient(cmd_app_id, append(client_opts, gomojo.WithAuthToken(cmd_auth_token))...)
+	} else if token_store != nil {
+		// The Auth Token is kept in the token file for later invocations,
+		// and renewed with the username/password (if given) when it expires.
+		if cmd_username != "" && cmd_passwd != "" {
+			client_opts = append(client_opts, gomojo.WithCredentialProvider(gomojo.StaticCredentials{Username: cmd_username, Password: cmd_passwd}))
+		}
+		mojo_client = gomojo.NewClient(cmd_app_id, client_opts...)
 	} else {
-		gomojo.InitGomojoWithUserPass(cmd_api_ver, cmd_app_id, cmd_username, cmd_passwd)
+		mojo_client = gomojo.NewClient(cmd_app_id, append(client_opts, gomojo.WithUserPass(cmd_username, cmd_passwd))...)
 
 		if cmd_action != "auth" {
 			authenticated_in_current = true
@@ -199,7 +381,7 @@ func main() {
 	// (except when 'auth' command-line action was specified).
 	if authenticated_in_current {
 		fmt.Println("Destructing the Auth Token generated specifically for this session.")
-		cmd_auth_token = gomojo.GetCurrentAuthToken()
+		cmd_auth_token = mojo_client.GetCurrentAuthToken()
 		processCommandLineAPI("deauth")
 	}
 }
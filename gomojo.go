@@ -11,7 +11,14 @@
 //
 // Currently available APIs:
 //
-// Initialization:
+// Client:
+// 		NewClient (all Main APIs and Helper Functions are also Client methods)
+// 		Each Main API also has a ...Context variant on Client (e.g. ListOffersContext)
+// 		that honours cancellation and deadlines of the given context.Context.
+// 		UploadReader (Client only): like UploadFile, but from an io.Reader.
+// 		Do (Client only): calls any API endpoint, e.g. one not wrapped here.
+//
+// Initialization (of the package-level default Client):
 // 		InitGomojoWithAuthToken
 // 		InitGomojoWithUserPass
 //
@@ -19,6 +26,7 @@
 // 		ListOffers
 //		GetOfferDetails
 //		ArchiveOffer
+//		UnarchiveOffer
 //		UploadFile
 //		CreateOffer
 //		UpdateOffer
@@ -27,22 +35,29 @@
 //
 // Helper Functions:
 // 		GetCurrentAuthToken
+// 		SetCurrentAuthToken
 //
 
 package gomojo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
-	"mime/multipart"
 	"path/filepath"
-	"io"
-	"net/url"
+	"strings"
 )
 
+// The ...Response types below describe the responses of the API.
+// The Client decodes them with the generic Envelope instead.
+
 // ListOffersResponse: represents response of 'offer' API
 type ListOffersResponse struct {
 	Offers  []Offer `json:"offers"`
@@ -65,9 +80,9 @@ type ArchiveResponse struct {
 
 // FileUploadResonse: represents response of 'getfileuploadurl' POST API
 type FileUploadResonse struct {
-	UploadURL string `json:"upload_url"`
-	Message string `json:"message"`
-	Success bool   `json:"success"`
+	UploadURL  string `json:"upload_url"`
+	Message    string `json:"message"`
+	Success    bool   `json:"success"`
 	UploadJSON string // Additional field populated later with upload response JSON
 }
 
@@ -88,415 +103,528 @@ type DeAuthResponse struct {
 // This is an amalgamation of the fields received as a
 // result of various APIs (Offers List / Offer Details)
 // Note that Offers List API doesn't populate everything.
+// The `form` tags name the fields sent by CreateOffer/UpdateOffer.
 type Offer struct {
-	ShortURL       string `json:"shorturl"`
-	Title          string `json:"title"`
-	Slug           string `json:"slug"`
-	Status         string `json:"status"`
-	Description    string `json:"description"`
-	Currency       string `json:"currency"`
-	BasePrice      string `json:"base_price"`
-	Quantity       string `json:"quantity"`
-	StartDate      string `json:"start_date"`
-	EndDate        string `json:"end_date"`
-	Timezone       string `json:"timezone"`
-	Venue          string `json:"venue"`
-	RedirectURL    string `json:"redirect_url"`
-	Note           string `json:"note"`
-	FileUploadJSON string `json:"file_upload_json"`
-	CoverImageJSON string `json:"cover_image_json"`
+	ShortURL       string `json:"shorturl" form:"-"`
+	Title          string `json:"title" form:"title"`
+	Slug           string `json:"slug" form:"-"`
+	Status         string `json:"status" form:"-"`
+	Description    string `json:"description" form:"description"`
+	Currency       string `json:"currency" form:"currency"`
+	BasePrice      string `json:"base_price" form:"base_price"`
+	Quantity       string `json:"quantity" form:"quantity"`
+	StartDate      string `json:"start_date" form:"start_date"`
+	EndDate        string `json:"end_date" form:"end_date"`
+	Timezone       string `json:"timezone" form:"timezone"`
+	Venue          string `json:"venue" form:"venue"`
+	RedirectURL    string `json:"redirect_url" form:"redirect_url"`
+	Note           string `json:"note" form:"note"`
+	FileUploadJSON string `json:"file_upload_json" form:"file_upload_json"`
+	CoverImageJSON string `json:"cover_image_json" form:"cover_image_json"`
+
+	// Extra: fields of the API response that Offer does not know (yet)
+	Extra map[string]json.RawMessage `json:"-" form:"-"`
 }
 
-var gomojo_app_id, gomojo_auth_token, gomojo_api_ver, gomojo_version string
-var gomojo_username, gomojo_password string
-var gomojo_init_done bool
+// ListOffers: retrieves the list of all offers created under the given App(ID)
+// Inputs: None
+// Returns: (Offer object array, error)
+func (c *Client) ListOffers() ([]Offer, error) {
+	return c.ListOffersContext(context.Background())
+}
 
-// InitGomojoWithAuthToken: Initialize gomojo with Auth Token
-// Inputs: (API version string, App ID string, Auth Token string)
-func InitGomojoWithAuthToken(api_ver, app_id, auth_token string) {
+// ListOffersContext: same as ListOffers, but the API request(s) are bound to ctx
+// and aborted when ctx is cancelled or its deadline passes.
+// Inputs: (Context)
+// Returns: (Offer object array, error)
+func (c *Client) ListOffersContext(ctx context.Context) ([]Offer, error) {
+
+	if !c.initDone {
+		return nil, ErrNotInitialized
+	}
+
+	jsonobj := NewEnvelope[[]Offer]("offers")
+
+	err := c.callAPI(ctx, "listoffers", "", nil, jsonobj)
+
+	return jsonobj.Data, err
+}
+
+// GetOfferDetails: retrieves the details of a particular offer
+// Inputs: (Offer-Slug string)
+// Returns: (Offer object, error)
+func (c *Client) GetOfferDetails(offer_slug string) (Offer, error) {
+	return c.GetOfferDetailsContext(context.Background(), offer_slug)
+}
 
-	if api_ver != "" && app_id != "" && auth_token != "" {
-		gomojo_app_id = app_id
-		gomojo_auth_token = auth_token
-		gomojo_api_ver = api_ver
+// GetOfferDetailsContext: same as GetOfferDetails, but the API request(s) are bound to ctx
+// and aborted when ctx is cancelled or its deadline passes.
+// Inputs: (Context, Offer-Slug string)
+// Returns: (Offer object, error)
+func (c *Client) GetOfferDetailsContext(ctx context.Context, offer_slug string) (Offer, error) {
 
-		gomojo_init_done = true
+	if !c.initDone {
+		return Offer{}, ErrNotInitialized
 	}
+
+	jsonobj := NewEnvelope[Offer]("offer")
+
+	err := c.callAPI(ctx, "offerdetails", offer_slug, nil, jsonobj)
+
+	return jsonobj.Data, err
 }
 
-// initGomojoWithUserPass: Initialize gomojo with username/password
-// Inputs: (API version string, App ID string, Username string, Password string)
-// Note: The username/password are internally stored in gomojo variables
-// until the first attempt to call an API. Afterwards, these internal variables
-// are blanked out.
-func InitGomojoWithUserPass(api_ver, app_id, username, password string) {
+// ArchiveOffer: archives an existing Offer
+// Inputs: (Offer Slug string)
+// Returns: (error)
+func (c *Client) ArchiveOffer(offer_slug string) error {
+	return c.ArchiveOfferContext(context.Background(), offer_slug)
+}
 
-	if api_ver != "" && app_id != "" && username != "" && password != "" {
-		gomojo_app_id = app_id
-		gomojo_api_ver = api_ver
-		// These variables are reset to blank once they're used
-		gomojo_username = username
-		gomojo_password = password
+// ArchiveOfferContext: same as ArchiveOffer, but the API request(s) are bound to ctx
+// and aborted when ctx is cancelled or its deadline passes.
+// Inputs: (Context, Offer Slug string)
+// Returns: (error)
+func (c *Client) ArchiveOfferContext(ctx context.Context, offer_slug string) error {
 
-		gomojo_init_done = true
+	if !c.initDone {
+		return ErrNotInitialized
 	}
+
+	jsonobj := NewEnvelope[struct{}]("")
+
+	return c.callAPI(ctx, "archiveoffer", offer_slug, nil, jsonobj)
 }
 
-// GetCurrentAuthToken: returns the current Auth Token
-// Inputs: None
-// Returns (Auth Token string)
-func GetCurrentAuthToken() string {
-	return gomojo_auth_token
+// UnarchiveOffer: restores an archived Offer, by setting its status back to Live
+// Inputs: (Offer Slug string)
+// Returns: (Offer object, error)
+func (c *Client) UnarchiveOffer(offer_slug string) (Offer, error) {
+	return c.UnarchiveOfferContext(context.Background(), offer_slug)
 }
 
-// SetCurrentAuthToken: sets the current Auth Token
-// Inputs: (Auth Token string)
-func SetCurrentAuthToken(auth_token string) {
-	gomojo_auth_token = auth_token
+// UnarchiveOfferContext: same as UnarchiveOffer, but the API request(s) are bound to ctx
+// and aborted when ctx is cancelled or its deadline passes.
+// Inputs: (Context, Offer Slug string)
+// Returns: (Offer object, error)
+func (c *Client) UnarchiveOfferContext(ctx context.Context, offer_slug string) (Offer, error) {
+	return c.UpdateOfferContext(ctx, offer_slug, OfferPatch{Status: String(string(OfferStatusLive))})
 }
 
-// ListOffers: retrieves the list of all offers created under the given App(ID)
-// Inputs: None
-// Returns: (Offer object array, API success bool, Message string)
-func ListOffers() ([]Offer, bool, string) {
+// UploadFile: uploads a File (content) or Cover Image
+// Attach the result to an offer with AttachFile or AttachCoverImage.
+// Inputs: (File Path string)
+// Returns: (UploadResult object, error)
+func (c *Client) UploadFile(file_path string) (UploadResult, error) {
+	return c.UploadFileContext(context.Background(), file_path)
+}
 
-	jsonobj := new(ListOffersResponse)
+// UploadFileContext: same as UploadFile, but the API request(s) are bound to ctx
+// and aborted when ctx is cancelled or its deadline passes.
+// Inputs: (Context, File Path string)
+// Returns: (UploadResult object, error)
+func (c *Client) UploadFileContext(ctx context.Context, file_path string) (UploadResult, error) {
 
-	if gomojo_init_done {
+	if !c.initDone {
+		return UploadResult{}, ErrNotInitialized
+	}
 
-		api_result := callAPI("listoffers", "", "")
+	// Check for file existence and readability
+	file, err := os.Open(file_path)
+	if err != nil {
+		return UploadResult{}, err
+	}
+	defer file.Close()
 
-		jsonerr := json.Unmarshal([]byte(api_result), jsonobj)
+	info, err := file.Stat()
+	if err != nil {
+		return UploadResult{}, err
+	}
 
-		if jsonerr != nil {
-			jsonobj.Message = "Invalid JSON: " + jsonerr.Error()
+	return c.UploadReaderContext(ctx, filepath.Base(file_path), file, info.Size())
+}
+
+// CreateOffer: create a new offer
+// Inputs: (Offer object)
+// Returns: (Offer object, error)
+func (c *Client) CreateOffer(offer Offer) (Offer, error) {
+	return c.CreateOfferContext(context.Background(), offer)
+}
+
+// CreateOfferContext: same as CreateOffer, but the API request(s) are bound to ctx
+// and aborted when ctx is cancelled or its deadline passes.
+// Inputs: (Context, Offer object)
+// Returns: (Offer object, error)
+func (c *Client) CreateOfferContext(ctx context.Context, offer Offer) (Offer, error) {
+
+	if !c.initDone {
+		return Offer{}, ErrNotInitialized
+	}
+
+	if !c.skipValidation {
+		if err := offer.Validate(); err != nil {
+			return Offer{}, err
 		}
-	} else {
-		jsonobj.Message = "Please call gomojo.InitGomojoWithAuthToken() or gomojo.InitGomojoWithUserPass() first."
 	}
 
-	return jsonobj.Offers, jsonobj.Success, jsonobj.Message
-}
+	jsonobj := NewEnvelope[Offer]("offer")
 
-// GetOfferDetails: retrieves the details of a particular offer
-// Inputs: (Offer-Slug string)
-// Returns: (Offer object, API success bool, Message string)
-func GetOfferDetails(offer_slug string) (Offer, bool, string) {
+	err := c.callAPI(ctx, "createoffer", "", encodeForm(offer), jsonobj)
 
-	jsonobj := new(OfferDetailsResponse)
+	return jsonobj.Data, err
+}
 
-	if gomojo_init_done {
+// UpdateOffer: update an existing offer
+// Only the fields set in the patch are sent; see OfferPatch, DiffOffers
+// and FullOfferPatch.
+// Inputs: (Offer-slug string, OfferPatch object)
+// Returns: (Offer object, error)
+func (c *Client) UpdateOffer(offer_slug string, patch OfferPatch) (Offer, error) {
+	return c.UpdateOfferContext(context.Background(), offer_slug, patch)
+}
 
-		api_result := callAPI("offerdetails", offer_slug, "")
+// UpdateOfferContext: same as UpdateOffer, but the API request(s) are bound to ctx
+// and aborted when ctx is cancelled or its deadline passes.
+// Inputs: (Context, Offer-slug string, OfferPatch object)
+// Returns: (Offer object, error)
+func (c *Client) UpdateOfferContext(ctx context.Context, offer_slug string, patch OfferPatch) (Offer, error) {
 
-		jsonerr := json.Unmarshal([]byte(api_result), jsonobj)
+	if !c.initDone {
+		return Offer{}, ErrNotInitialized
+	}
 
-		if jsonerr != nil {
-			jsonobj.Message = "Invalid JSON: " + jsonerr.Error()
+	if !c.skipValidation {
+		if err := patch.Validate(); err != nil {
+			return Offer{}, err
 		}
-	} else {
-		jsonobj.Message = "Please call gomojo.InitGomojoWithAuthToken() or gomojo.InitGomojoWithUserPass() first."
 	}
 
-	return jsonobj.Offer, jsonobj.Success, jsonobj.Message
+	jsonobj := NewEnvelope[Offer]("offer")
+
+	err := c.callAPI(ctx, "updateoffer", offer_slug, encodeForm(patch), jsonobj)
+
+	return jsonobj.Data, err
 }
 
-// ArchiveOffer: archives an existing Offer
-// Inputs: (Offer Slug string)
-// Returns: (API success bool, Message string)
-func ArchiveOffer(offer_slug string) (bool, string) {
+// GetNewAuthToken: gets a new Auth Token
+// Inputs: (Username string, Password string)
+// Returns: (Auth Token string, error)
+func (c *Client) GetNewAuthToken(username, password string) (string, error) {
+	return c.GetNewAuthTokenContext(context.Background(), username, password)
+}
 
-	jsonobj := new(ArchiveResponse)
+// GetNewAuthTokenContext: same as GetNewAuthToken, but the API request(s) are bound to ctx
+// and aborted when ctx is cancelled or its deadline passes.
+// Inputs: (Context, Username string, Password string)
+// Returns: (Auth Token string, error)
+func (c *Client) GetNewAuthTokenContext(ctx context.Context, username, password string) (string, error) {
 
-	if gomojo_init_done {
+	if !c.initDone {
+		return "", ErrNotInitialized
+	}
 
-		api_result := callAPI("offer", offer_slug, "")
+	jsonobj := NewEnvelope[string]("token")
 
-		jsonerr := json.Unmarshal([]byte(api_result), jsonobj)
+	err := c.callAPI(ctx, "auth", "", encodeForm(AuthRequest{Username: username, Password: password}), jsonobj)
 
-		if jsonerr != nil {
-			jsonobj.Message = "Invalid JSON: " + jsonerr.Error()
-		}
-	} else {
-		jsonobj.Message = "Please call gomojo.InitGomojoWithAuthToken() or gomojo.InitGomojoWithUserPass() first."
-	}
+	return jsonobj.Data, err
+}
 
-	return jsonobj.Success, jsonobj.Message
+// DeleteAuthToken: deletes an existing Auth Token
+// Inputs: (Auth Token string)
+// Returns: (error)
+func (c *Client) DeleteAuthToken(auth_token string) error {
+	return c.DeleteAuthTokenContext(context.Background(), auth_token)
 }
 
-// UploadFile: uploads a File (content) or Cover Image
-// Inputs: (File Path string)
-// Returns: (API success bool, APUI Message string, UploadURL string, Upload-File JSON string)
-func UploadFile(file_path string) (bool, string, string, string) {
+// DeleteAuthTokenContext: same as DeleteAuthToken, but the API request(s) are bound to ctx
+// and aborted when ctx is cancelled or its deadline passes.
+// Inputs: (Context, Auth Token string)
+// Returns: (error)
+func (c *Client) DeleteAuthTokenContext(ctx context.Context, auth_token string) error {
 
-	jsonobj := new(FileUploadResonse)
+	if !c.initDone {
+		return ErrNotInitialized
+	}
 
-	if gomojo_init_done {
+	jsonobj := NewEnvelope[struct{}]("")
 
-		api_result := callAPI("getfileuploadurl", "", "")
+	err := c.callAPI(ctx, "deauth", auth_token, nil, jsonobj)
+	if err != nil {
+		return err
+	}
 
-		jsonerr := json.Unmarshal([]byte(api_result), jsonobj)
+	// Forget the deleted Auth Token, if it is the current/stored one
+	c.mu.Lock()
+	if c.authToken == auth_token {
+		c.authToken = ""
+	}
+	c.mu.Unlock()
 
-		if jsonerr != nil {
-			jsonobj.Message = "Invalid JSON: " + jsonerr.Error()
+	if c.tokens != nil {
+		stored_token, load_err := c.tokens.LoadToken(c.appID)
+		if load_err == nil && stored_token == auth_token {
+			err = c.tokens.DeleteToken(c.appID)
 		}
-	} else {
-		jsonobj.Message = "Please call gomojo.InitGomojoWithAuthToken() or gomojo.InitGomojoWithUserPass() first."
 	}
 
-	if jsonobj.Success {
+	return err
+}
 
-		// Check for file existence and readability
-		file, err := os.Open(file_path)
-		if err != nil {
-		  jsonobj.Message = err.Error()
-		} else {
-			defer file.Close()
-
-			  body := &bytes.Buffer{}
-			  writer := multipart.NewWriter(body)
-			  part, err := writer.CreateFormFile("fileUpload", filepath.Base(file_path))
-			  if err != nil {
-			      jsonobj.Message = err.Error()
-			  } else {
-				   _, err = io.Copy(part, file)
-				  
-				  err = writer.Close()
-				  if err != nil {
-				    jsonobj.Message = err.Error()
-				  } else {
-
-				  	client := &http.Client{}
-					request, _ := http.NewRequest("POST", jsonobj.UploadURL, body)
-					
-				  	resp, err := client.Do(request)
-					if err != nil {
-						jsonobj.Message = err.Error()
-					} else {
-						respbody := &bytes.Buffer{}
-						_, err := respbody.ReadFrom(resp.Body)
-					    if err != nil {
-							jsonobj.Message = err.Error()
-						} else {
-							resp.Body.Close()
-					    	jsonobj.UploadJSON = respbody.String()
-						}
-					    
-					}
-
-				  }
-			  }
-			  
+// roundTripUpload: the innermost Handler for the upload POST
+func (c *Client) roundTripUpload(call *Call) error {
+
+	resp, err := c.do(call.Request)
+	if err != nil {
+		return transportError(call.Request.Method, call.Endpoint, err)
+	}
+	defer resp.Body.Close()
 
+	call.Response = resp
 
+	respbody := &bytes.Buffer{}
+	_, err = respbody.ReadFrom(resp.Body)
+	call.ResponseBody = respbody.Bytes()
+	if err != nil {
+		return transportError(call.Request.Method, call.Endpoint, err)
+	}
+
+	api_err := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     call.Request.Method,
+		Endpoint:   call.Endpoint,
+		Body:       call.ResponseBody,
+	}
+
+	result := call.Result.(*UploadResult)
+	err = result.parse(call.ResponseBody)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Prefer the upload server's message, if it sent one
+		api_err.Message = result.Message
+		return api_err
+	}
+
+	if err != nil {
+		if errors.Is(err, ErrInvalidResponse) {
+			api_err.Cause = fmt.Errorf("%w: %w", ErrInvalidResponse, err)
 		}
+		api_err.Message = err.Error()
+		return api_err
+	}
 
+	if drift_err := c.checkDrift(call); drift_err != nil {
+		api_err.Cause = drift_err
+		api_err.Message = "Schema drift: " + driftList(drift_err.Drifts)
+		return api_err
 	}
 
-	return jsonobj.Success, jsonobj.Message, jsonobj.UploadURL, jsonobj.UploadJSON
+	return nil
 }
 
-// CreateOffer: create a new offer
-// Inputs: (Offer object)
-// Returns: (Offer object, API success bool, Message string)
-func CreateOffer(offer Offer) (Offer, bool, string) {
+// resolveURL: resolves a (possibly relative) URL returned by the API
+// against the base URL of this Client
+func (c *Client) resolveURL(ref string) (string, error) {
 
-	jsonobj := new(OfferDetailsResponse)
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", err
+	}
 
-	if gomojo_init_done {
+	ref_url, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
 
-		api_data := "title=" + url.QueryEscape(offer.Title) +
-			"&description=" + url.QueryEscape(offer.Description) +
-			"&currency=" + url.QueryEscape(offer.Currency) +
-			"&base_price=" + url.QueryEscape(offer.BasePrice) +
-			"&quantity=" + url.QueryEscape(offer.Quantity) +
-			"&start_date=" + url.QueryEscape(offer.StartDate) +
-			"&end_date=" + url.QueryEscape(offer.EndDate) +
-			"&timezone=" + url.QueryEscape(offer.Timezone) +
-			"&venue=" + url.QueryEscape(offer.Venue) +
-			"&redirect_url=" + url.QueryEscape(offer.RedirectURL) +
-			"&note=" + url.QueryEscape(offer.Note) +
-			"&file_upload_json=" + url.QueryEscape(offer.FileUploadJSON) +
-			"&cover_image_json=" + url.QueryEscape(offer.CoverImageJSON)
+	return base.ResolveReference(ref_url).String(), nil
+}
 
-		api_result := callAPI("createoffer", "", api_data)
+// apiStatus: the success/message pair present in every API response
+type apiStatus struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
 
-		jsonerr := json.Unmarshal([]byte(api_result), jsonobj)
+// sendEndpoint: Internal function handling the REST API
+// form is sent as the endpoint's body encoding prescribes. The response is
+// decoded into api_response. Unsuccessful responses are returned as
+// *APIError and connectivity problems wrap ErrTransport.
+func (c *Client) sendEndpoint(ctx context.Context, api_endpoint endpoint, target string, form url.Values, api_response interface{}) error {
 
-		if jsonerr != nil {
-			jsonobj.Message = "Invalid JSON: " + jsonerr.Error()
+	// Check if we have auth token available.
+	// If not, let's first authenticate and retrieve it.
+	auth_token := ""
+	if !api_endpoint.anonymous {
+		var err error
+		if auth_token, err = c.currentAuthToken(ctx); err != nil {
+			return err
 		}
-	} else {
-		jsonobj.Message = "Please call gomojo.InitGomojoWithAuthToken() or gomojo.InitGomojoWithUserPass() first."
 	}
 
-	return jsonobj.Offer, jsonobj.Success, jsonobj.Message
-}
+	path := api_endpoint.expand(target)
 
-// UpdateOffer: update an existing offer
-// Inputs: (Offer-slug string, Offer object)
-// Returns: (Offer object, API success bool, Message string)
-func UpdateOffer(offer_slug string, offer Offer) (Offer, bool, string) {
-
-	jsonobj := new(OfferDetailsResponse)
-
-	if gomojo_init_done {
-
-		api_data := "title=" + url.QueryEscape(offer.Title) +
-			"&description=" + url.QueryEscape(offer.Description) +
-			"&currency=" + url.QueryEscape(offer.Currency) +
-			"&base_price=" + url.QueryEscape(offer.BasePrice) +
-			"&quantity=" + url.QueryEscape(offer.Quantity) +
-			"&start_date=" + url.QueryEscape(offer.StartDate) +
-			"&end_date=" + url.QueryEscape(offer.EndDate) +
-			"&timezone=" + url.QueryEscape(offer.Timezone) +
-			"&venue=" + url.QueryEscape(offer.Venue) +
-			"&redirect_url=" + url.QueryEscape(offer.RedirectURL) +
-			"&note=" + url.QueryEscape(offer.Note) +
-			"&file_upload_json=" + url.QueryEscape(offer.FileUploadJSON) +
-			"&cover_image_json=" + url.QueryEscape(offer.CoverImageJSON)
-
-		api_result := callAPI("updateoffer", offer_slug, api_data)
-
-		jsonerr := json.Unmarshal([]byte(api_result), jsonobj)
-
-		if jsonerr != nil {
-			jsonobj.Message = "Invalid JSON: " + jsonerr.Error()
+	var param_data []byte
+	if api_endpoint.body == bodyForm {
+		param_data = []byte(form.Encode())
+	} else if api_endpoint.body == bodyQuery && len(form) > 0 {
+		// path may already have a query string (see Do): merge form into it
+		path_url, err := url.Parse(path)
+		if err != nil {
+			return fmt.Errorf("gomojo: invalid API path %q: %w", path, err)
+		}
+
+		query := path_url.Query()
+		for key, values := range form {
+			query[key] = append(query[key], values...)
+		}
+		path_url.RawQuery = query.Encode()
+		path = path_url.String()
+	}
+
+	err := c.sendAPI(ctx, api_endpoint.operation, api_endpoint.method, path, param_data, auth_token, api_response)
+
+	// The Auth Token may have expired or been revoked: get a new one
+	// and replay the request, if we can re-authenticate.
+	if !api_endpoint.anonymous && c.credentials != nil && errors.Is(err, ErrUnauthorized) {
+
+		if auth_token, err = c.refreshAuthToken(ctx, auth_token); err != nil {
+			return err
 		}
-	} else {
-		jsonobj.Message = "Please call gomojo.InitGomojoWithAuthToken() or gomojo.InitGomojoWithUserPass() first."
+
+		err = c.sendAPI(ctx, api_endpoint.operation, api_endpoint.method, path, param_data, auth_token, api_response)
 	}
 
-	return jsonobj.Offer, jsonobj.Success, jsonobj.Message
+	return err
 }
 
-// GetNewAuthToken: gets a new Auth Token
-// Inputs: (Username string, Password string)
-// Returns: (Auth Token string, API success bool, Message string)
-func GetNewAuthToken(username, password string) (string, bool, string) {
+// sendAPI: sends one API request and decodes its response into api_response
+// The X-Auth-Token header is only sent when auth_token is not blank.
+func (c *Client) sendAPI(ctx context.Context, operation, api_method, api_endpoint string, param_data []byte, auth_token string, api_response interface{}) error {
 
-	jsonobj := new(AuthResponse)
+	// Make the API URL to call
+	api_url := c.baseURL + c.apiVersion + "/" + api_endpoint
 
-	if gomojo_init_done {
+	req, err := http.NewRequestWithContext(ctx, api_method, api_url, bytes.NewReader(param_data))
+	if err != nil {
+		return transportError(api_method, api_url, err)
+	}
 
-		api_result := callAPI("auth", "", "username="+username+"&password="+password)
+	req.Header.Add("X-App-Id", c.appID)
 
-		jsonerr := json.Unmarshal([]byte(api_result), jsonobj)
+	if auth_token != "" {
+		req.Header.Add("X-Auth-Token", auth_token)
+	}
 
-		if jsonerr != nil {
-			jsonobj.Message = "Invalid JSON: " + jsonerr.Error()
-		}
-	} else {
-		jsonobj.Message = "Please call gomojo.InitGomojoWithAuthToken() or gomojo.InitGomojoWithUserPass() first."
+	if param_data != nil {
+		req.Header.Set("Content-Type", formContentType)
 	}
 
-	return jsonobj.Token, jsonobj.Success, jsonobj.Message
-}
+	call := &Call{
+		Operation: operation,
+		Endpoint:  redactEndpoint(api_endpoint),
+		Request:   req,
+		Result:    api_response,
+	}
 
-// DeleteAuthToken: deletes an existing Auth Token
-// Inputs: (Auth Token string)
-// Returns: (API success bool, Message string)
-func DeleteAuthToken(auth_token string) (bool, string) {
+	return c.intercept(c.roundTripAPI)(call)
+}
 
-	jsonobj := new(DeAuthResponse)
+// roundTripAPI: the innermost Handler for API requests
+// Sends call.Request and decodes the response into call.Result.
+// Non-2xx responses become an *APIError carrying the Instamojo message (if
+// the body has one); 2xx responses that are not complete JSON of the right
+// Content-Type become an *APIError with an ErrInvalidResponse or
+// ErrTruncatedResponse Cause.
+func (c *Client) roundTripAPI(call *Call) error {
+
+	resp, err := c.do(call.Request)
+	if err != nil {
+		return transportError(call.Request.Method, call.Request.URL.String(), err)
+	}
+	defer resp.Body.Close()
 
-	if gomojo_init_done {
+	bodybytes, read_err := ioutil.ReadAll(resp.Body)
 
-		api_result := callAPI("deauth", auth_token, "")
+	call.Response = resp
+	call.ResponseBody = bodybytes
 
-		jsonerr := json.Unmarshal([]byte(api_result), jsonobj)
+	if read_err != nil && call.Request.Context().Err() != nil {
+		return transportError(call.Request.Method, call.Request.URL.String(), read_err)
+	}
 
-		if jsonerr != nil {
-			jsonobj.Message = "Invalid JSON: " + jsonerr.Error()
-		}
-	} else {
-		jsonobj.Message = "Please call gomojo.InitGomojoWithAuthToken() or gomojo.InitGomojoWithUserPass() first."
+	api_err := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     call.Request.Method,
+		Endpoint:   call.Endpoint,
+		Body:       bodybytes,
 	}
 
-	return jsonobj.Success, jsonobj.Message
-}
+	if read_err != nil {
+		api_err.Cause = fmt.Errorf("%w: %w", ErrTruncatedResponse, read_err)
+		api_err.Message = "Truncated response: " + read_err.Error()
+	} else if resp.ContentLength > int64(len(bodybytes)) {
+		api_err.Cause = ErrTruncatedResponse
+		api_err.Message = fmt.Sprintf("Truncated response: got %d of %d bytes", len(bodybytes), resp.ContentLength)
+	}
 
-// callAPI: Internal function handling the REST API
-// Valid apicall values: "auth", "deauth", "listoffers"
-func callAPI(apicall, apitarget, apidata string) string {
+	status := new(apiStatus)
+	jsonerr := json.Unmarshal(bodybytes, status)
 
-	// Check if we have auth token available.
-	// If not, let's first authenticate and retrieve it.
-	if apicall != "auth" && gomojo_auth_token == "" {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// The failure is what matters; the body only adds the message
+		if jsonerr == nil {
+			api_err.Message = status.Message
+		}
+		api_err.Cause = nil
+		return api_err
+	}
 
-		new_auth_token, new_auth_success, _ := GetNewAuthToken(gomojo_username, gomojo_password)
+	if api_err.Cause != nil {
+		return api_err
+	}
 
-		gomojo_username = ""
-		gomojo_password = ""
+	if content_type := resp.Header.Get("Content-Type"); !isJSONContentType(content_type) {
+		api_err.Cause = ErrInvalidResponse
+		api_err.Message = "Unexpected Content-Type " + content_type
+		return api_err
+	}
 
-		if new_auth_token == "" || !new_auth_success {
-			api_result := "{\"success\":false, \"message\":\"Unable to get a valid Auth Token from API.\" }"
-			return api_result
-		} else {
-			gomojo_auth_token = new_auth_token
+	if jsonerr == nil && status.Success {
+		if drift_err := c.checkDrift(call); drift_err != nil {
+			api_err.Cause = drift_err
+			api_err.Message = "Schema drift: " + driftList(drift_err.Drifts)
+			return api_err
 		}
 	}
 
-	api_result := ""
-	client := &http.Client{}
-	api_method := "GET" // overridden later
-	var param_data []byte
-	var param_reader *bytes.Reader
-	param_data = ([]byte)("")
-
-	// Decide on the HTTP Method and Parameters
-	if apicall == "auth" {
-		api_method = "POST"
-		param_data = ([]byte)(apidata)
-	} else if apicall == "deauth" {
-		api_method = "DELETE"
-		apicall = "auth/" + apitarget
-	} else if apicall == "listoffers" {
-		api_method = "GET"
-		apicall = "offer"
-	} else if apicall == "offerdetails" {
-		api_method = "GET"
-		apicall = "offer/" + apitarget
-	} else if apicall == "archiveoffer" {
-		api_method = "DELETE"
-		apicall = "offer/" + apitarget
-	} else if apicall == "getfileuploadurl" {
-		api_method = "GET"
-		apicall = "offer/" + "get_file_upload_url"
-	} else if apicall == "createoffer" {
-		api_method = "POST"
-		apicall = "offer"
-		param_data = ([]byte)(apidata)
-	}  else if apicall == "updateoffer" {
-		api_method = "PATCH"
-		apicall = "offer/" + apitarget
-		param_data = ([]byte)(apidata)
-	} else {
-		api_method = "GET"
+	if jsonerr == nil && call.Result != nil {
+		jsonerr = json.Unmarshal(bodybytes, call.Result)
+	}
+	if jsonerr != nil {
+		api_err.Cause = fmt.Errorf("%w: %w", ErrInvalidResponse, jsonerr)
+		api_err.Message = "Invalid JSON: " + jsonerr.Error()
+		return api_err
 	}
 
-	// Make the API URL to call
-	api_url := "https://www.instamojo.com/api/" + gomojo_api_ver + "/" + apicall + "/"
+	if !status.Success {
+		api_err.Message = status.Message
+		return api_err
+	}
 
-	param_reader = bytes.NewReader(param_data)
+	return nil
+}
 
-	req, err := http.NewRequest(api_method, api_url, param_reader)
-	if err == nil {
-		req.Header.Add("X-App-Id", gomojo_app_id)
+// isJSONContentType: whether content_type is JSON (or absent)
+func isJSONContentType(content_type string) bool {
 
-		if apicall != "auth" {
-			req.Header.Add("X-Auth-Token", gomojo_auth_token)
-		}
+	if content_type == "" {
+		return true
+	}
 
-		resp, resperr := client.Do(req)
-		if resperr != nil {
-			api_result = "{\"success\":false, \"message\":\"Error connecting to or retrieving response from API URL. Please check connectivity. API URL: " + api_url + "\" }"
-		} else {
-			defer resp.Body.Close()
-			bodybytes, _ := ioutil.ReadAll(resp.Body)
-			api_result = string(bodybytes)
-		}
+	media_type, _, err := mime.ParseMediaType(content_type)
+	if err != nil {
+		return false
 	}
 
-	return api_result
+	return media_type == "application/json" || media_type == "text/json" || strings.HasSuffix(media_type, "+json")
 }
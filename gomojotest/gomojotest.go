@@ -0,0 +1,636 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+//
+// Package gomojotest provides an in-process fake Instamojo API server,
+// so that code built on gomojo can be integration-tested offline.
+//
+// The fake Server emulates the offer, auth, get_file_upload_url and file
+// upload endpoints with in-memory state. It can be seeded with offers,
+// told to fail or slow down per endpoint, and records every request it
+// receives for later assertions.
+//
+// Typical usage:
+//
+//	srv := gomojotest.NewServer()
+//	defer srv.Close()
+//	srv.SeedOffers(gomojo.Offer{Title: "Test Product", Slug: "test-product"})
+//	client := srv.NewClient()
+//	offers, err := client.ListOffers()
+package gomojotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dotmanish/gomojo"
+)
+
+// Endpoint names, as used by Fail, SetFault and RequestsFor.
+// These match the operation names used within gomojo.
+const (
+	EndpointAuth             = "auth"
+	EndpointDeauth           = "deauth"
+	EndpointListOffers       = "listoffers"
+	EndpointOfferDetails     = "offerdetails"
+	EndpointArchiveOffer     = "archiveoffer"
+	EndpointGetFileUploadURL = "getfileuploadurl"
+	EndpointCreateOffer      = "createoffer"
+	EndpointUpdateOffer      = "updateoffer"
+	EndpointUpload           = "upload"
+	EndpointUnknown          = "unknown"
+)
+
+// Defaults used by NewServer
+const (
+	DefaultAppID     = "test-app-id"
+	DefaultAuthToken = "test-auth-token"
+)
+
+// Fault: an injected failure and/or latency for one endpoint
+type Fault struct {
+	StatusCode  int           // HTTP status code to respond with (0: no failure, only Delay)
+	Message     string        // "message" of the JSON failure response
+	Body        string        // raw response body, overriding the JSON failure response
+	ContentType string        // Content-Type of Body (default: application/json if Body is JSON, else sniffed)
+	Truncate    bool          // announce a longer Content-Length than Body, then cut the response short
+	RetryAfter  string        // Retry-After header of the failure response, e.g. "2"
+	Delay       time.Duration // latency added before responding
+	Times       int           // number of requests affected (0: all of them)
+}
+
+// Request: one request received by the Server
+type Request struct {
+	Endpoint string      // endpoint name, e.g. EndpointCreateOffer
+	Method   string      // HTTP method
+	Path     string      // URL path
+	Query    url.Values  // decoded URL query
+	Header   http.Header // request headers (X-App-Id, X-Auth-Token, ...)
+	Form     url.Values  // decoded form body (only for Content-Type application/x-www-form-urlencoded)
+	Body     []byte      // raw request body
+
+	ContentLength int64 // Content-Length of the request (-1 if sent chunked)
+}
+
+// Upload: one file received by the fake upload endpoint
+type Upload struct {
+	FileName string
+	Content  []byte
+}
+
+// Server: an in-process fake Instamojo API
+type Server struct {
+	*httptest.Server
+
+	// AppID: the App ID expected in the X-App-Id header ("" accepts any)
+	// Set it before sending requests; all other methods are safe for
+	// concurrent use.
+	AppID string
+
+	mu          sync.Mutex
+	offers      map[string]gomojo.Offer
+	offer_order []string
+	users       map[string]string
+	tokens      map[string]bool
+	faults      map[string]*Fault
+	requests    []Request
+	uploads     []Upload
+	token_seq   int
+}
+
+// NewServer: starts a new fake Instamojo API server
+// The server accepts DefaultAppID and DefaultAuthToken; use AddUser/AddToken
+// to accept more credentials. Call Close when done.
+func NewServer() *Server {
+
+	s := &Server{
+		AppID:  DefaultAppID,
+		offers: make(map[string]gomojo.Offer),
+		users:  make(map[string]string),
+		tokens: map[string]bool{DefaultAuthToken: true},
+		faults: make(map[string]*Fault),
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// BaseURL: the API root of this Server, for gomojo.WithBaseURL
+func (s *Server) BaseURL() string {
+	return s.URL + "/api/"
+}
+
+// NewClient: creates a gomojo Client talking to this Server with
+// DefaultAppID and DefaultAuthToken. opts are applied afterwards,
+// so they can override the credentials.
+func (s *Server) NewClient(opts ...gomojo.ClientOption) *gomojo.Client {
+
+	client_opts := []gomojo.ClientOption{
+		gomojo.WithBaseURL(s.BaseURL()),
+		gomojo.WithAuthToken(DefaultAuthToken),
+	}
+
+	return gomojo.NewClient(DefaultAppID, append(client_opts, opts...)...)
+}
+
+// AddUser: accepts the given username/password on the auth endpoint
+func (s *Server) AddUser(username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[username] = password
+}
+
+// AddToken: accepts the given Auth Token
+func (s *Server) AddToken(auth_token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[auth_token] = true
+}
+
+// RevokeToken: stops accepting the given Auth Token
+func (s *Server) RevokeToken(auth_token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, auth_token)
+}
+
+// ValidToken: whether the given Auth Token is currently accepted
+func (s *Server) ValidToken(auth_token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[auth_token]
+}
+
+// SeedOffers: adds (or replaces) offers in the in-memory state
+// Offers without a Slug get one derived from their Title.
+func (s *Server) SeedOffers(offers ...gomojo.Offer) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, offer := range offers {
+		s.putOffer(offer)
+	}
+}
+
+// Offer: returns the offer with the given slug from the in-memory state
+func (s *Server) Offer(offer_slug string) (gomojo.Offer, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offer, ok := s.offers[offer_slug]
+	return offer, ok
+}
+
+// Offers: returns all offers in the in-memory state, in creation order
+func (s *Server) Offers() []gomojo.Offer {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	offers := make([]gomojo.Offer, 0, len(s.offer_order))
+	for _, slug := range s.offer_order {
+		offers = append(offers, s.offers[slug])
+	}
+
+	return offers
+}
+
+// SetFault: injects a failure and/or latency on the given endpoint
+// A zero Fault removes any fault from the endpoint.
+func (s *Server) SetFault(endpoint string, fault Fault) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fault == (Fault{}) {
+		delete(s.faults, endpoint)
+	} else {
+		s.faults[endpoint] = &fault
+	}
+}
+
+// Fail: makes the given endpoint respond with an unsuccessful JSON response
+func (s *Server) Fail(endpoint string, status_code int, message string) {
+	s.SetFault(endpoint, Fault{StatusCode: status_code, Message: message})
+}
+
+// Delay: adds latency to every response of the given endpoint
+func (s *Server) Delay(endpoint string, delay time.Duration) {
+	s.SetFault(endpoint, Fault{Delay: delay})
+}
+
+// Requests: returns all requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsFor: returns the requests received so far on the given endpoint
+func (s *Server) RequestsFor(endpoint string) []Request {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var requests []Request
+	for _, req := range s.requests {
+		if req.Endpoint == endpoint {
+			requests = append(requests, req)
+		}
+	}
+
+	return requests
+}
+
+// LastRequest: returns the most recent request received (false if none)
+func (s *Server) LastRequest() (Request, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.requests) == 0 {
+		return Request{}, false
+	}
+
+	return s.requests[len(s.requests)-1], true
+}
+
+// Uploads: returns all files received by the upload endpoint
+func (s *Server) Uploads() []Upload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Upload(nil), s.uploads...)
+}
+
+// ClearRequests: forgets all recorded requests and uploads
+func (s *Server) ClearRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	s.uploads = nil
+}
+
+// serveHTTP: routes and records every request
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	endpoint, api_target := route(r.Method, r.URL.Path)
+
+	req := Request{
+		Endpoint: endpoint,
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Query:    r.URL.Query(),
+		Header:   r.Header.Clone(),
+
+		ContentLength: r.ContentLength,
+	}
+
+	// The upload body is recorded by serveUpload, as Upload
+	if endpoint != EndpointUpload {
+		req.Body, _ = ioutil.ReadAll(r.Body)
+		req.Form = make(url.Values)
+
+		// Like the real API, only form-encoded bodies are understood
+		media_type, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if media_type == "application/x-www-form-urlencoded" {
+			req.Form, _ = url.ParseQuery(string(req.Body))
+		}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	fault := s.takeFault(endpoint)
+	s.mu.Unlock()
+
+	if fault != nil {
+		if fault.Delay > 0 {
+			select {
+			case <-time.After(fault.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if fault.StatusCode != 0 {
+			if fault.RetryAfter != "" {
+				w.Header().Set("Retry-After", fault.RetryAfter)
+			}
+			if fault.Body != "" {
+				content_type := fault.ContentType
+				if content_type == "" && json.Valid([]byte(fault.Body)) {
+					content_type = "application/json"
+				}
+				if content_type != "" {
+					w.Header().Set("Content-Type", content_type)
+				}
+				if fault.Truncate {
+					w.Header().Set("Content-Length", strconv.Itoa(2*len(fault.Body)))
+				}
+				w.WriteHeader(fault.StatusCode)
+				io.WriteString(w, fault.Body)
+			} else {
+				writeFailure(w, fault.StatusCode, fault.Message)
+			}
+			return
+		}
+	}
+
+	if endpoint == EndpointUnknown {
+		writeFailure(w, http.StatusNotFound, "Unknown API endpoint.")
+		return
+	}
+
+	// The upload URL is a separate upload server, which gets no API headers
+	if endpoint == EndpointUpload {
+		s.serveUpload(w, r)
+		return
+	}
+
+	if s.AppID != "" && r.Header.Get("X-App-Id") != s.AppID {
+		writeFailure(w, http.StatusUnauthorized, "Invalid App ID.")
+		return
+	}
+
+	if endpoint != EndpointAuth && !s.ValidToken(r.Header.Get("X-Auth-Token")) {
+		writeFailure(w, http.StatusUnauthorized, "Invalid or expired Auth Token.")
+		return
+	}
+
+	switch endpoint {
+	case EndpointAuth:
+		s.serveAuth(w, req.Form)
+	case EndpointDeauth:
+		s.RevokeToken(api_target)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "message": "Auth Token deleted."})
+	case EndpointListOffers:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "offers": s.Offers()})
+	case EndpointOfferDetails:
+		s.serveOffer(w, api_target, nil, "")
+	case EndpointArchiveOffer:
+		s.serveOffer(w, api_target, nil, "Archived")
+	case EndpointCreateOffer:
+		s.serveCreateOffer(w, req.Form)
+	case EndpointUpdateOffer:
+		s.serveOffer(w, api_target, req.Form, "")
+	case EndpointGetFileUploadURL:
+		// Like the real one, the upload URL is signed
+		upload_url := fmt.Sprintf("%s/upload/?expires=%d&signature=fake-%d", s.URL, time.Now().Add(time.Hour).Unix(), len(s.Requests()))
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "upload_url": upload_url})
+	}
+}
+
+// route: maps an HTTP method and path to an endpoint name and its target
+// (Offer slug or Auth Token)
+func route(method, path string) (string, string) {
+
+	if method == "POST" && path == "/upload/" {
+		return EndpointUpload, ""
+	}
+
+	// Paths look like /api/<version>/<resource>/[<target>/]
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "api" {
+		return EndpointUnknown, ""
+	}
+	parts = parts[2:]
+
+	switch {
+	case parts[0] == "auth" && len(parts) == 1 && method == "POST":
+		return EndpointAuth, ""
+	case parts[0] == "auth" && len(parts) == 2 && method == "DELETE":
+		return EndpointDeauth, parts[1]
+	case parts[0] == "offer" && len(parts) == 1 && method == "GET":
+		return EndpointListOffers, ""
+	case parts[0] == "offer" && len(parts) == 1 && method == "POST":
+		return EndpointCreateOffer, ""
+	case parts[0] == "offer" && len(parts) == 2 && parts[1] == "get_file_upload_url" && method == "GET":
+		return EndpointGetFileUploadURL, ""
+	case parts[0] == "offer" && len(parts) == 2 && method == "GET":
+		return EndpointOfferDetails, parts[1]
+	case parts[0] == "offer" && len(parts) == 2 && method == "PATCH":
+		return EndpointUpdateOffer, parts[1]
+	case parts[0] == "offer" && len(parts) == 2 && method == "DELETE":
+		return EndpointArchiveOffer, parts[1]
+	}
+
+	return EndpointUnknown, ""
+}
+
+// takeFault: returns the fault to apply to a request on endpoint, if any
+// Must be called with s.mu held.
+func (s *Server) takeFault(endpoint string) *Fault {
+
+	fault, ok := s.faults[endpoint]
+	if !ok {
+		return nil
+	}
+
+	if fault.Times > 0 {
+		fault.Times--
+		if fault.Times == 0 {
+			delete(s.faults, endpoint)
+		}
+	}
+
+	return fault
+}
+
+// serveAuth: handles the auth POST
+func (s *Server) serveAuth(w http.ResponseWriter, form url.Values) {
+
+	s.mu.Lock()
+	password, ok := s.users[form.Get("username")]
+	ok = ok && password == form.Get("password")
+	auth_token := ""
+	if ok {
+		s.token_seq++
+		auth_token = fmt.Sprintf("token-%d", s.token_seq)
+		s.tokens[auth_token] = true
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeFailure(w, http.StatusUnauthorized, "Invalid username or password.")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "token": auth_token})
+}
+
+// serveCreateOffer: handles the offer POST
+func (s *Server) serveCreateOffer(w http.ResponseWriter, form url.Values) {
+
+	offer := gomojo.Offer{Status: "Live"}
+	applyForm(&offer, form)
+
+	if offer.Title == "" {
+		writeFailure(w, http.StatusBadRequest, "Title is required.")
+		return
+	}
+
+	s.mu.Lock()
+	offer = s.putOffer(offer)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"success": true, "offer": offer})
+}
+
+// serveOffer: handles the offer details GET, PATCH (form != nil)
+// and DELETE (status != "")
+func (s *Server) serveOffer(w http.ResponseWriter, offer_slug string, form url.Values, status string) {
+
+	s.mu.Lock()
+	offer, ok := s.offers[offer_slug]
+	if ok {
+		if form != nil {
+			applyForm(&offer, form)
+		}
+		if status != "" {
+			offer.Status = status
+		}
+		s.offers[offer_slug] = offer
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeFailure(w, http.StatusNotFound, "Offer not found.")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "offer": offer})
+}
+
+// serveUpload: handles the multipart POST to the upload URL
+func (s *Server) serveUpload(w http.ResponseWriter, r *http.Request) {
+
+	file, header, err := r.FormFile("fileUpload")
+	if err != nil {
+		writeFailure(w, http.StatusBadRequest, "Missing fileUpload: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		writeFailure(w, http.StatusBadRequest, "Unable to read fileUpload: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.uploads = append(s.uploads, Upload{FileName: header.Filename, Content: content})
+	file_id := fmt.Sprintf("file-%d", len(s.uploads))
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"file_id":  file_id,
+		"filename": header.Filename,
+		"size":     len(content),
+	})
+}
+
+// putOffer: stores offer, deriving its Slug and ShortURL if needed
+// Must be called with s.mu held.
+func (s *Server) putOffer(offer gomojo.Offer) gomojo.Offer {
+
+	if offer.Slug == "" {
+		offer.Slug = s.uniqueSlug(slugify(offer.Title))
+	}
+	if offer.ShortURL == "" {
+		offer.ShortURL = s.URL + "/o/" + offer.Slug
+	}
+
+	if _, exists := s.offers[offer.Slug]; !exists {
+		s.offer_order = append(s.offer_order, offer.Slug)
+	}
+	s.offers[offer.Slug] = offer
+
+	return offer
+}
+
+// uniqueSlug: returns slug, suffixed if needed to not clash with an existing offer
+// Must be called with s.mu held.
+func (s *Server) uniqueSlug(slug string) string {
+
+	if slug == "" {
+		slug = "offer"
+	}
+
+	unique := slug
+	for i := 2; ; i++ {
+		if _, exists := s.offers[unique]; !exists {
+			return unique
+		}
+		unique = fmt.Sprintf("%s-%d", slug, i)
+	}
+}
+
+// slugify: derives an Offer slug from its title
+func slugify(title string) string {
+
+	var slug strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			slug.WriteRune(r)
+			dash = false
+		} else if !dash && slug.Len() > 0 {
+			slug.WriteByte('-')
+			dash = true
+		}
+	}
+
+	return strings.TrimSuffix(slug.String(), "-")
+}
+
+// offer_form_fields: form field name -> Offer field, for create/update
+var offer_form_fields = map[string]func(*gomojo.Offer) *string{
+	"title":            func(o *gomojo.Offer) *string { return &o.Title },
+	"description":      func(o *gomojo.Offer) *string { return &o.Description },
+	"currency":         func(o *gomojo.Offer) *string { return &o.Currency },
+	"base_price":       func(o *gomojo.Offer) *string { return &o.BasePrice },
+	"quantity":         func(o *gomojo.Offer) *string { return &o.Quantity },
+	"start_date":       func(o *gomojo.Offer) *string { return &o.StartDate },
+	"end_date":         func(o *gomojo.Offer) *string { return &o.EndDate },
+	"timezone":         func(o *gomojo.Offer) *string { return &o.Timezone },
+	"venue":            func(o *gomojo.Offer) *string { return &o.Venue },
+	"redirect_url":     func(o *gomojo.Offer) *string { return &o.RedirectURL },
+	"note":             func(o *gomojo.Offer) *string { return &o.Note },
+	"file_upload_json": func(o *gomojo.Offer) *string { return &o.FileUploadJSON },
+	"cover_image_json": func(o *gomojo.Offer) *string { return &o.CoverImageJSON },
+	"status":           func(o *gomojo.Offer) *string { return &o.Status },
+}
+
+// applyForm: sets the Offer fields present in form
+func applyForm(offer *gomojo.Offer, form url.Values) {
+
+	for key := range form {
+		if field, ok := offer_form_fields[key]; ok {
+			*field(offer) = form.Get(key)
+		}
+	}
+}
+
+// writeFailure: writes an unsuccessful JSON response
+func writeFailure(w http.ResponseWriter, status_code int, message string) {
+	writeJSON(w, status_code, map[string]interface{}{"success": false, "message": message})
+}
+
+// writeJSON: writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status_code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status_code)
+	json.NewEncoder(w).Encode(v)
+}
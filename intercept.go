@@ -0,0 +1,64 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"net/http"
+)
+
+// Call: one API request passing through the interceptor chain
+// Before calling the next Handler, an Interceptor may inspect or modify
+// Request (e.g. to add headers). After it returns, Response, ResponseBody
+// and Result are filled in as far as the call got.
+type Call struct {
+	Operation    string         // logical operation, e.g. "listoffers", "createoffer", "upload"
+	Endpoint     string         // API endpoint, e.g. "offer/my-offer-slug/" (upload URL for "upload"), secrets redacted
+	Request      *http.Request  // HTTP request to send
+	Response     *http.Response // HTTP response (its Body is already consumed, see ResponseBody)
+	ResponseBody []byte         // raw response body
+	Result       interface{}    // decoded response, e.g. *Envelope[[]Offer] (*UploadResult for "upload")
+}
+
+// Handler: performs a Call, returning the error the API method will return
+type Handler func(call *Call) error
+
+// Interceptor: wraps a Handler with cross-cutting behaviour (logging,
+// metrics, header injection, request mutation, ...)
+//
+//	func timing(next gomojo.Handler) gomojo.Handler {
+//		return func(call *gomojo.Call) error {
+//			start := time.Now()
+//			err := next(call)
+//			log.Println(call.Operation, time.Since(start), err)
+//			return err
+//		}
+//	}
+type Interceptor func(next Handler) Handler
+
+// WithInterceptors: pass every API request through the given interceptors
+// The first interceptor is the outermost one. Every HTTP request (including
+// the upload POST, and a request replayed after refreshing the Auth Token)
+// is a separate Call; retries happen within the innermost Handler.
+func WithInterceptors(interceptors ...Interceptor) ClientOption {
+	return func(c *Client) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// intercept: wraps handler with the interceptors of this Client
+func (c *Client) intercept(handler Handler) Handler {
+
+	// Logging is innermost, so it sees the requests as finally sent
+	if c.logger != nil {
+		handler = c.logCalls(handler)
+	}
+
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		handler = c.interceptors[i](handler)
+	}
+
+	return handler
+}
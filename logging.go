@@ -0,0 +1,162 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// redacted: replaces secrets in logged values
+const redacted = "REDACTED"
+
+// WithLogger: log every API request (and the upload POST) to logger
+// Each request is logged with its operation, method, endpoint URL, HTTP
+// status, latency and outcome; at Debug level, the request headers and form
+// body are logged too. Secrets are redacted: the X-Auth-Token header, the
+// password in the auth form body, the Auth Token in the deauth URL and the
+// signed query string of the upload URL. Response bodies are never logged.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// logCalls: a Handler wrapper logging each Call to the logger of this Client
+func (c *Client) logCalls(next Handler) Handler {
+	return func(call *Call) error {
+
+		start := time.Now()
+		err := next(call)
+		latency := time.Since(start)
+
+		ctx := call.Request.Context()
+
+		attrs := []slog.Attr{
+			slog.String("operation", call.Operation),
+			slog.String("method", call.Request.Method),
+			slog.String("url", redactURL(call.Request.URL)),
+		}
+		if call.Response != nil {
+			attrs = append(attrs, slog.Int("status", call.Response.StatusCode))
+		}
+		attrs = append(attrs, slog.Duration("latency", latency))
+
+		if c.logger.Enabled(ctx, slog.LevelDebug) {
+			attrs = append(attrs, slog.Any("headers", redactHeader(call.Request.Header)))
+			if call.Operation != "upload" {
+				attrs = append(attrs, slog.String("body", redactForm(call.Request)))
+			}
+		}
+
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+			c.logger.LogAttrs(ctx, slog.LevelWarn, "gomojo: API call failed", attrs...)
+		} else {
+			c.logger.LogAttrs(ctx, slog.LevelInfo, "gomojo: API call", attrs...)
+		}
+
+		return err
+	}
+}
+
+// logWarn: logs a problem not failing the API call, if this Client has a logger
+func (c *Client) logWarn(ctx context.Context, msg string, err error) {
+	if c.logger != nil {
+		c.logger.LogAttrs(ctx, slog.LevelWarn, msg, slog.String("error", err.Error()))
+	}
+}
+
+// redactURL: the URL with the deauth Auth Token and any query string values
+// (e.g. the signature of the upload URL) redacted
+func redactURL(u *url.URL) string {
+
+	r := *u
+	r.User = nil
+
+	// Deauth URLs look like .../auth/<Auth Token>/
+	parts := strings.Split(r.Path, "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "auth" && parts[i+1] != "" {
+			parts[i+1] = redacted
+		}
+	}
+	r.Path = strings.Join(parts, "/")
+	r.RawPath = ""
+
+	if r.RawQuery != "" {
+		query := r.Query()
+		for key := range query {
+			query[key] = []string{redacted}
+		}
+		r.RawQuery = query.Encode()
+	}
+
+	return r.String()
+}
+
+// redactEndpoint: the endpoint (a URL, or a path relative to the API root)
+// redacted like redactURL, for errors and Call.Endpoint
+func redactEndpoint(endpoint string) string {
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return redacted
+	}
+
+	return redactURL(u)
+}
+
+// redactHeader: the headers with X-Auth-Token (and other credentials) redacted
+func redactHeader(header http.Header) http.Header {
+
+	r := header.Clone()
+
+	for _, key := range []string{"X-Auth-Token", "Authorization", "Cookie"} {
+		if r.Get(key) != "" {
+			r.Set(key, redacted)
+		}
+	}
+
+	return r
+}
+
+// redactForm: the form body of req with the password redacted
+func redactForm(req *http.Request) string {
+
+	if req.GetBody == nil {
+		return ""
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	var data strings.Builder
+	if _, err = io.Copy(&data, body); err != nil {
+		return ""
+	}
+
+	form, err := url.ParseQuery(data.String())
+	if err != nil {
+		return redacted
+	}
+
+	for key := range form {
+		if strings.Contains(strings.ToLower(key), "password") {
+			form[key] = []string{redacted}
+		}
+	}
+
+	return form.Encode()
+}
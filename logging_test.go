@@ -0,0 +1,64 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/dotmanish/gomojo"
+	"github.com/dotmanish/gomojo/gomojotest"
+)
+
+func TestLoggerRedactsSecrets(t *testing.T) {
+
+	const secret_token = "SECRET-TOKEN-123"
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	srv := gomojotest.NewServer()
+	defer srv.Close()
+	client := srv.NewClient(gomojo.WithLogger(logger))
+
+	var errs []error
+
+	// API failure of deauth: the Auth Token is in the path
+	srv.Fail(gomojotest.EndpointDeauth, 500, "boom")
+	errs = append(errs, client.DeleteAuthToken(secret_token))
+
+	// Upload failure: the upload URL is signed ("signature=fake-N")
+	srv.Fail(gomojotest.EndpointUpload, 500, "boom")
+	_, err := client.UploadReader("file.txt", strings.NewReader("content"), 7)
+	errs = append(errs, err)
+
+	// Transport failure of deauth
+	down := gomojotest.NewServer()
+	down_client := down.NewClient(gomojo.WithLogger(logger))
+	down.Close()
+	errs = append(errs, down_client.DeleteAuthToken(secret_token))
+
+	for _, err := range errs {
+		if err == nil {
+			t.Fatal("expected the call to fail")
+		}
+		if strings.Contains(err.Error(), secret_token) || strings.Contains(err.Error(), "signature=fake") {
+			t.Errorf("error leaks a secret: %v", err)
+		}
+	}
+
+	for _, secret := range []string{secret_token, gomojotest.DefaultAuthToken, "signature=fake"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("log output contains %q:\n%s", secret, logs.String())
+		}
+	}
+
+	if !strings.Contains(logs.String(), "gomojo: API call failed") {
+		t.Errorf("failed calls were not logged:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"reflect"
+)
+
+// OfferPatch: represents a partial update of an Offer, for UpdateOffer
+// Only the fields set (non-nil) are sent, so the other fields of the Offer
+// are left untouched. Use String to set a field:
+//
+//	patch := gomojo.OfferPatch{BasePrice: gomojo.String("499.00")}
+type OfferPatch struct {
+	Title          *string `form:"title"`
+	Status         *string `form:"status"`
+	Description    *string `form:"description"`
+	Currency       *string `form:"currency"`
+	BasePrice      *string `form:"base_price"`
+	Quantity       *string `form:"quantity"`
+	StartDate      *string `form:"start_date"`
+	EndDate        *string `form:"end_date"`
+	Timezone       *string `form:"timezone"`
+	Venue          *string `form:"venue"`
+	RedirectURL    *string `form:"redirect_url"`
+	Note           *string `form:"note"`
+	FileUploadJSON *string `form:"file_upload_json"`
+	CoverImageJSON *string `form:"cover_image_json"`
+}
+
+// String: returns a pointer to s, for setting OfferPatch fields
+func String(s string) *string {
+	return &s
+}
+
+// IsEmpty: whether the patch sets no field at all
+func (p OfferPatch) IsEmpty() bool {
+	return len(encodeForm(p)) == 0
+}
+
+// DiffOffers: returns the OfferPatch turning from into to
+// Only the fields whose values differ are set.
+func DiffOffers(from, to Offer) OfferPatch {
+	return makeOfferPatch(to, func(name string) bool {
+		return reflect.ValueOf(from).FieldByName(name).String() != reflect.ValueOf(to).FieldByName(name).String()
+	})
+}
+
+// FullOfferPatch: returns the OfferPatch setting every field CreateOffer
+// would send (i.e. all but Status) to its value in offer, blank or not.
+// This is what UpdateOffer sent before OfferPatch existed.
+func FullOfferPatch(offer Offer) OfferPatch {
+	return makeOfferPatch(offer, func(name string) bool {
+		return name != "Status"
+	})
+}
+
+// makeOfferPatch: sets the OfferPatch fields selected by include
+// (by field name) to their values in offer
+func makeOfferPatch(offer Offer, include func(name string) bool) OfferPatch {
+
+	var patch OfferPatch
+
+	patch_value := reflect.ValueOf(&patch).Elem()
+	offer_value := reflect.ValueOf(offer)
+
+	for i := 0; i < patch_value.NumField(); i++ {
+
+		name := patch_value.Type().Field(i).Name
+		if !include(name) {
+			continue
+		}
+
+		field_value := offer_value.FieldByName(name).String()
+		patch_value.Field(i).Set(reflect.ValueOf(&field_value))
+	}
+
+	return patch
+}
@@ -0,0 +1,261 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The Offer fields BasePrice, Quantity, StartDate, EndDate and Status are
+// kept as the strings the API uses. The typed accessors below parse them
+// (and the Set... methods format them back) so consumers don't have to.
+// Setting the value a field already has keeps its string as it is (e.g.
+// "unlimited" or "499.5"), so reading and writing back an Offer is lossless.
+
+// Money: an amount in minor units, e.g. paise for INR or cents for USD
+// (all currencies supported by Instamojo have 2 decimal places)
+type Money int64
+
+// MoneyMinorDigits: number of decimal places of Money in its string form
+const MoneyMinorDigits = 2
+
+// ParseMoney: parses a decimal amount like "499", "499.5" or "499.50"
+// Amounts with more than MoneyMinorDigits decimal places are rejected,
+// since they can't be represented without losing precision.
+func ParseMoney(s string) (Money, error) {
+
+	s = strings.TrimSpace(s)
+
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(s, "-")
+
+	major, minor, has_minor := strings.Cut(digits, ".")
+	if major == "" || (has_minor && (minor == "" || len(minor) > MoneyMinorDigits)) ||
+		strings.Trim(major+minor, "0123456789") != "" {
+		return 0, fmt.Errorf("gomojo: invalid amount %q", s)
+	}
+
+	minor += strings.Repeat("0", MoneyMinorDigits-len(minor))
+
+	amount, err := strconv.ParseInt(major+minor, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("gomojo: invalid amount %q: %w", s, err)
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return Money(amount), nil
+}
+
+// String: formats the amount like the API does, e.g. "499.50"
+func (m Money) String() string {
+
+	sign := ""
+	amount := int64(m)
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+// Quantity: number of units of an Offer on sale, or UnlimitedQuantity
+type Quantity int
+
+// UnlimitedQuantity: no limit on the units on sale (a blank quantity in the API)
+const UnlimitedQuantity Quantity = -1
+
+// IsUnlimited: whether there is no limit on the units on sale
+func (q Quantity) IsUnlimited() bool {
+	return q < 0
+}
+
+// String: formats the quantity like the API does (blank for unlimited)
+func (q Quantity) String() string {
+
+	if q.IsUnlimited() {
+		return ""
+	}
+
+	return strconv.Itoa(int(q))
+}
+
+// OfferStatus: status of an Offer
+// Values not listed below are kept as they are.
+type OfferStatus string
+
+// Known Offer statuses
+const (
+	OfferStatusLive     OfferStatus = "Live"
+	OfferStatusArchived OfferStatus = "Archived"
+)
+
+// OfferDateFormat: format of the Offer start_date/end_date fields,
+// in the Offer's Timezone
+const OfferDateFormat = "2006-01-02 15:04"
+
+// offer_date_formats: formats accepted when parsing start_date/end_date
+var offer_date_formats = []string{
+	OfferDateFormat,
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// Price: the BasePrice as Money (zero if blank)
+func (o Offer) Price() (Money, error) {
+
+	if strings.TrimSpace(o.BasePrice) == "" {
+		return 0, nil
+	}
+
+	return ParseMoney(o.BasePrice)
+}
+
+// SetPrice: sets the BasePrice from Money
+func (o *Offer) SetPrice(price Money) {
+
+	if current, err := o.Price(); err == nil && current == price {
+		return
+	}
+
+	o.BasePrice = price.String()
+}
+
+// Qty: the Quantity as a number, or UnlimitedQuantity if blank/"unlimited"
+func (o Offer) Qty() (Quantity, error) {
+
+	quantity := strings.TrimSpace(o.Quantity)
+	if quantity == "" || strings.EqualFold(quantity, "unlimited") {
+		return UnlimitedQuantity, nil
+	}
+
+	n, err := strconv.Atoi(quantity)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("gomojo: invalid quantity %q", o.Quantity)
+	}
+
+	return Quantity(n), nil
+}
+
+// SetQty: sets the Quantity from a number (or UnlimitedQuantity)
+func (o *Offer) SetQty(quantity Quantity) {
+
+	if current, err := o.Qty(); err == nil && (current == quantity || (current.IsUnlimited() && quantity.IsUnlimited())) {
+		return
+	}
+
+	o.Quantity = quantity.String()
+}
+
+// State: the Status as OfferStatus
+func (o Offer) State() OfferStatus {
+	return OfferStatus(o.Status)
+}
+
+// SetState: sets the Status from an OfferStatus
+func (o *Offer) SetState(status OfferStatus) {
+	o.Status = string(status)
+}
+
+// Location: the time zone named by Timezone (UTC if blank)
+// Time zones are looked up in the zoneinfo of the host; programs running on
+// hosts without it (e.g. minimal containers) should import _ "time/tzdata".
+func (o Offer) Location() (*time.Location, error) {
+
+	if o.Timezone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(o.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("gomojo: invalid timezone %q: %w", o.Timezone, err)
+	}
+
+	return loc, nil
+}
+
+// StartTime: the StartDate, interpreted in the Offer's Timezone
+// (zero time if blank)
+func (o Offer) StartTime() (time.Time, error) {
+	return o.parseDate(o.StartDate)
+}
+
+// EndTime: the EndDate, interpreted in the Offer's Timezone
+// (zero time if blank)
+func (o Offer) EndTime() (time.Time, error) {
+	return o.parseDate(o.EndDate)
+}
+
+// SetStartTime: sets the StartDate, in the Offer's Timezone
+// A zero time blanks the StartDate.
+func (o *Offer) SetStartTime(t time.Time) error {
+	return o.formatDate(&o.StartDate, t)
+}
+
+// SetEndTime: sets the EndDate, in the Offer's Timezone
+// A zero time blanks the EndDate.
+func (o *Offer) SetEndTime(t time.Time) error {
+	return o.formatDate(&o.EndDate, t)
+}
+
+// parseDate: parses a start_date/end_date in the Offer's Timezone
+func (o Offer) parseDate(date string) (time.Time, error) {
+
+	date = strings.TrimSpace(date)
+	if date == "" {
+		return time.Time{}, nil
+	}
+
+	loc, err := o.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, layout := range offer_date_formats {
+		if t, err := time.ParseInLocation(layout, date, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("gomojo: invalid date %q (expected %q)", date, OfferDateFormat)
+}
+
+// formatDate: formats t into *date in the Offer's Timezone
+func (o Offer) formatDate(date *string, t time.Time) error {
+
+	if current, err := o.parseDate(*date); err == nil && current.Equal(t) {
+		return nil
+	}
+
+	if t.IsZero() {
+		*date = ""
+		return nil
+	}
+
+	loc, err := o.Location()
+	if err != nil {
+		return err
+	}
+
+	t = t.In(loc)
+
+	// Keep seconds, rather than silently dropping them
+	layout := OfferDateFormat
+	if t.Second() != 0 {
+		layout = "2006-01-02 15:04:05"
+	}
+
+	*date = t.Format(layout)
+
+	return nil
+}
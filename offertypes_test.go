@@ -0,0 +1,207 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dotmanish/gomojo"
+)
+
+func TestParseMoney(t *testing.T) {
+
+	tests := []struct {
+		s       string
+		money   gomojo.Money
+		invalid bool
+	}{
+		{s: "499", money: 49900},
+		{s: "499.5", money: 49950},
+		{s: "499.50", money: 49950},
+		{s: "0.05", money: 5},
+		{s: " 12.30 ", money: 1230},
+		{s: "007", money: 700},
+		{s: "-1.25", money: -125},
+		{s: "-0", money: 0},
+		{s: "", invalid: true},
+		{s: "-", invalid: true},
+		{s: "abc", invalid: true},
+		{s: "1.999", invalid: true},
+		{s: "0.001", invalid: true},
+		{s: "1.", invalid: true},
+		{s: ".5", invalid: true},
+		{s: "1,000", invalid: true},
+		{s: "--1", invalid: true},
+		{s: "1.-5", invalid: true},
+		{s: "+1", invalid: true},
+		{s: "99999999999999999999", invalid: true},
+	}
+
+	for _, test := range tests {
+
+		money, err := gomojo.ParseMoney(test.s)
+
+		if test.invalid {
+			if err == nil {
+				t.Errorf("ParseMoney(%q) = %v, want an error", test.s, money)
+			}
+		} else if err != nil || money != test.money {
+			t.Errorf("ParseMoney(%q) = %v, %v, want %v", test.s, int64(money), err, int64(test.money))
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+
+	for money, want := range map[gomojo.Money]string{0: "0.00", 5: "0.05", 49950: "499.50", 100: "1.00", -125: "-1.25", -5: "-0.05"} {
+		if got := money.String(); got != want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(money), got, want)
+		}
+	}
+}
+
+func TestOfferQty(t *testing.T) {
+
+	tests := []struct {
+		s        string
+		quantity gomojo.Quantity
+		invalid  bool
+	}{
+		{s: "", quantity: gomojo.UnlimitedQuantity},
+		{s: "  ", quantity: gomojo.UnlimitedQuantity},
+		{s: "unlimited", quantity: gomojo.UnlimitedQuantity},
+		{s: "Unlimited", quantity: gomojo.UnlimitedQuantity},
+		{s: "0", quantity: 0},
+		{s: "10", quantity: 10},
+		{s: " 7 ", quantity: 7},
+		{s: "-1", invalid: true},
+		{s: "1.5", invalid: true},
+		{s: "ten", invalid: true},
+	}
+
+	for _, test := range tests {
+
+		quantity, err := gomojo.Offer{Quantity: test.s}.Qty()
+
+		if test.invalid {
+			if err == nil {
+				t.Errorf("Qty() of %q = %v, want an error", test.s, quantity)
+			}
+		} else if err != nil || quantity != test.quantity {
+			t.Errorf("Qty() of %q = %v, %v, want %v", test.s, int(quantity), err, int(test.quantity))
+		}
+	}
+
+	for quantity, want := range map[gomojo.Quantity]string{gomojo.UnlimitedQuantity: "", 0: "0", 42: "42"} {
+		if got := quantity.String(); got != want {
+			t.Errorf("Quantity(%d).String() = %q, want %q", int(quantity), got, want)
+		}
+	}
+}
+
+func TestOfferSettersRoundTrip(t *testing.T) {
+
+	// Writing back the value read keeps the API's string as it is
+	for _, s := range []string{"", "unlimited", "UNLIMITED", "007", "10"} {
+		offer := gomojo.Offer{Quantity: s}
+		quantity, _ := offer.Qty()
+		if offer.SetQty(quantity); offer.Quantity != s {
+			t.Errorf("SetQty(Qty()) changed %q to %q", s, offer.Quantity)
+		}
+	}
+
+	for _, s := range []string{"", "499.5", "499.50", "10", "0"} {
+		offer := gomojo.Offer{BasePrice: s}
+		price, _ := offer.Price()
+		if offer.SetPrice(price); offer.BasePrice != s {
+			t.Errorf("SetPrice(Price()) changed %q to %q", s, offer.BasePrice)
+		}
+	}
+
+	for _, s := range []string{"", "2024-01-01 10:00", "2024-01-01 10:00:00", "2024-01-01", "2024-01-01T10:00:00+05:30"} {
+		offer := gomojo.Offer{StartDate: s, EndDate: s, Timezone: "Asia/Kolkata"}
+		start, _ := offer.StartTime()
+		end, _ := offer.EndTime()
+		if err := offer.SetStartTime(start); err != nil || offer.StartDate != s {
+			t.Errorf("SetStartTime(StartTime()) changed %q to %q (%v)", s, offer.StartDate, err)
+		}
+		if err := offer.SetEndTime(end); err != nil || offer.EndDate != s {
+			t.Errorf("SetEndTime(EndTime()) changed %q to %q (%v)", s, offer.EndDate, err)
+		}
+	}
+
+	// New values are written in the canonical form
+	offer := gomojo.Offer{Quantity: "unlimited", BasePrice: "499.5"}
+	offer.SetQty(5)
+	offer.SetPrice(59950)
+	if offer.Quantity != "5" || offer.BasePrice != "599.50" {
+		t.Errorf("got quantity %q, price %q, want \"5\", \"599.50\"", offer.Quantity, offer.BasePrice)
+	}
+
+	offer.SetQty(gomojo.UnlimitedQuantity)
+	if offer.Quantity != "" {
+		t.Errorf("SetQty(UnlimitedQuantity) wrote %q, want \"\"", offer.Quantity)
+	}
+}
+
+func TestOfferTimes(t *testing.T) {
+
+	kolkata := gomojo.Offer{Timezone: "Asia/Kolkata"}
+
+	tests := []struct {
+		offer   gomojo.Offer
+		date    string
+		want    time.Time
+		invalid bool
+	}{
+		{offer: kolkata, date: "", want: time.Time{}},
+		{offer: kolkata, date: "2024-01-01 10:00", want: time.Date(2024, 1, 1, 4, 30, 0, 0, time.UTC)},
+		{offer: kolkata, date: "2024-01-01 10:00:15", want: time.Date(2024, 1, 1, 4, 30, 15, 0, time.UTC)},
+		{offer: kolkata, date: "2024-01-01", want: time.Date(2023, 12, 31, 18, 30, 0, 0, time.UTC)},
+		{offer: kolkata, date: "2024-01-01T10:00:00Z", want: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{offer: gomojo.Offer{}, date: "2024-01-01 10:00", want: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{offer: gomojo.Offer{Timezone: "America/New_York"}, date: "2024-07-01 10:00", want: time.Date(2024, 7, 1, 14, 0, 0, 0, time.UTC)},
+		{offer: kolkata, date: "01/01/2024", invalid: true},
+		{offer: gomojo.Offer{Timezone: "Mars/Olympus_Mons"}, date: "2024-01-01 10:00", invalid: true},
+	}
+
+	for _, test := range tests {
+
+		offer := test.offer
+		offer.StartDate = test.date
+		offer.EndDate = test.date
+
+		start, start_err := offer.StartTime()
+		end, end_err := offer.EndTime()
+
+		if test.invalid {
+			if start_err == nil || end_err == nil {
+				t.Errorf("StartTime()/EndTime() of %q in %q succeeded, want an error", test.date, test.offer.Timezone)
+			}
+		} else if start_err != nil || end_err != nil || !start.Equal(test.want) || !end.Equal(test.want) {
+			t.Errorf("StartTime()/EndTime() of %q in %q = %v/%v (%v/%v), want %v",
+				test.date, test.offer.Timezone, start, end, start_err, end_err, test.want)
+		}
+	}
+
+	// Setters convert to the Offer's Timezone, keeping any seconds
+	offer := kolkata
+	if err := offer.SetStartTime(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)); err != nil || offer.StartDate != "2024-01-01 17:30" {
+		t.Errorf("SetStartTime: got %q (%v), want \"2024-01-01 17:30\"", offer.StartDate, err)
+	}
+	if err := offer.SetEndTime(time.Date(2024, 1, 1, 12, 0, 15, 0, time.UTC)); err != nil || offer.EndDate != "2024-01-01 17:30:15" {
+		t.Errorf("SetEndTime: got %q (%v), want \"2024-01-01 17:30:15\"", offer.EndDate, err)
+	}
+	if err := offer.SetEndTime(time.Time{}); err != nil || offer.EndDate != "" {
+		t.Errorf("SetEndTime(zero): got %q (%v), want \"\"", offer.EndDate, err)
+	}
+
+	bad_zone := gomojo.Offer{Timezone: "Mars/Olympus_Mons"}
+	if err := bad_zone.SetStartTime(time.Now()); err == nil {
+		t.Error("SetStartTime with an unknown Timezone succeeded, want an error")
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy: controls automatic retries of failed API requests
+// A request is retried when it could not be sent or answered (transport
+// error), or when the response has one of RetryableStatusCodes.
+// Only safe/idempotent requests (GET, DELETE) are retried, unless
+// RetryNonIdempotent is set to also retry POST/PATCH requests.
+// A response whose Retry-After asks to wait longer than MaxBackoff is
+// returned without retrying (a shorter wait would likely be rejected again).
+type RetryPolicy struct {
+	MaxAttempts          int           // total attempts per request, including the first (<= 1: no retries)
+	InitialBackoff       time.Duration // wait before the first retry
+	MaxBackoff           time.Duration // upper bound of the wait between attempts (0: unbounded)
+	Multiplier           float64       // growth of the wait per attempt (< 1: 2)
+	Jitter               float64       // random fraction (0..1) of the wait added or removed
+	RetryableStatusCodes []int         // HTTP status codes worth retrying
+	RetryNonIdempotent   bool          // also retry POST/PATCH requests
+	IgnoreRetryAfter     bool          // do not honour the Retry-After response header
+}
+
+// DefaultRetryPolicy: returns a reasonable RetryPolicy for WithRetryPolicy:
+// 3 attempts, 200ms initial backoff doubling up to 5s, 20% jitter,
+// retrying 429, 502, 503 and 504 responses of GET/DELETE requests.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// WithRetryPolicy: retry failed API requests according to policy
+// By default, a Client does not retry.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// allowsMethod: whether requests with the given HTTP method may be retried
+func (p RetryPolicy) allowsMethod(method string) bool {
+
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "DELETE":
+		return true
+	}
+
+	return p.RetryNonIdempotent
+}
+
+// retryable: whether the outcome of an attempt is worth retrying
+func (p RetryPolicy) retryable(ctx context.Context, resp *http.Response, err error) bool {
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	for _, status_code := range p.RetryableStatusCodes {
+		if resp.StatusCode == status_code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff: the wait before the given retry (1 for the first retry)
+// Returns false if resp's Retry-After exceeds MaxBackoff: do not retry.
+func (p RetryPolicy) backoff(retry int, resp *http.Response) (time.Duration, bool) {
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	wait := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		wait += wait * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	if !p.IgnoreRetryAfter && resp != nil {
+		retry_after := parseRetryAfter(resp.Header.Get("Retry-After"))
+		if p.MaxBackoff > 0 && retry_after > p.MaxBackoff {
+			return 0, false
+		}
+		if retry_after > time.Duration(wait) {
+			return retry_after, true
+		}
+	}
+
+	return time.Duration(wait), true
+}
+
+// parseRetryAfter: parses a Retry-After header (delay in seconds or HTTP date)
+func parseRetryAfter(value string) time.Duration {
+
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if when, err := http.ParseTime(value); err == nil {
+		return time.Until(when)
+	}
+
+	return 0
+}
+
+// sleepContext: waits for d, or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// do: sends req, retrying it according to the RetryPolicy of this Client
+// Each attempt sends a clone of req, with a fresh body from req.GetBody.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+
+	ctx := req.Context()
+
+	max_attempts := 1
+	if c.retry.MaxAttempts > 1 && c.retry.allowsMethod(req.Method) && (req.Body == nil || req.GetBody != nil) {
+		max_attempts = c.retry.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+
+		attempt_req := req
+		if attempt > 1 {
+			attempt_req = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attempt_req.Body = body
+			}
+		}
+
+		resp, err := c.httpClient.Do(attempt_req)
+
+		if attempt >= max_attempts || !c.retry.retryable(ctx, resp, err) {
+			return resp, err
+		}
+
+		wait, ok := c.retry.backoff(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+
+		if resp != nil {
+			// Drain the body so the connection can be reused
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if sleep_err := sleepContext(ctx, wait); sleep_err != nil {
+			if err == nil {
+				err = sleep_err
+			}
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dotmanish/gomojo"
+	"github.com/dotmanish/gomojo/gomojotest"
+)
+
+// fastRetryPolicy: DefaultRetryPolicy, without waiting long between attempts
+func fastRetryPolicy() gomojo.RetryPolicy {
+
+	policy := gomojo.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 2 * time.Second
+
+	return policy
+}
+
+func TestRetryPolicy(t *testing.T) {
+
+	create := func(client *gomojo.Client) error {
+		_, err := client.CreateOffer(gomojo.Offer{Title: "New Product", BasePrice: "10"})
+		return err
+	}
+	update := func(client *gomojo.Client) error {
+		_, err := client.UpdateOffer("a", gomojo.OfferPatch{Note: gomojo.String("Updated")})
+		return err
+	}
+	details := func(client *gomojo.Client) error {
+		_, err := client.GetOfferDetails("a")
+		return err
+	}
+
+	tests := map[string]struct {
+		call        func(client *gomojo.Client) error
+		endpoint    string
+		fault       gomojotest.Fault
+		change      func(policy *gomojo.RetryPolicy)
+		attempts    int           // requests received by the endpoint
+		err         error         // the call fails with (nil: succeeds)
+		min_elapsed time.Duration // the call takes at least
+		max_elapsed time.Duration // the call takes at most (0: no limit)
+	}{
+		"retried until success": {
+			call: details, endpoint: gomojotest.EndpointOfferDetails,
+			fault:    gomojotest.Fault{StatusCode: 503, Times: 2},
+			attempts: 3,
+		},
+		"attempts exhausted": {
+			call: details, endpoint: gomojotest.EndpointOfferDetails,
+			fault:    gomojotest.Fault{StatusCode: 502},
+			attempts: 3, err: gomojo.ErrServerError,
+		},
+		"more attempts": {
+			call: details, endpoint: gomojotest.EndpointOfferDetails,
+			fault:    gomojotest.Fault{StatusCode: 504},
+			change:   func(policy *gomojo.RetryPolicy) { policy.MaxAttempts = 5 },
+			attempts: 5, err: gomojo.ErrServerError,
+		},
+		"no retries": {
+			call: details, endpoint: gomojotest.EndpointOfferDetails,
+			fault:    gomojotest.Fault{StatusCode: 503},
+			change:   func(policy *gomojo.RetryPolicy) { policy.MaxAttempts = 1 },
+			attempts: 1, err: gomojo.ErrServerError,
+		},
+		"status not retryable": {
+			call: details, endpoint: gomojotest.EndpointOfferDetails,
+			fault:    gomojotest.Fault{StatusCode: 500},
+			attempts: 1, err: gomojo.ErrServerError,
+		},
+		"POST not retried": {
+			call: create, endpoint: gomojotest.EndpointCreateOffer,
+			fault:    gomojotest.Fault{StatusCode: 503, Times: 1},
+			attempts: 1, err: gomojo.ErrServerError,
+		},
+		"POST retried with RetryNonIdempotent": {
+			call: create, endpoint: gomojotest.EndpointCreateOffer,
+			fault:    gomojotest.Fault{StatusCode: 503, Times: 1},
+			change:   func(policy *gomojo.RetryPolicy) { policy.RetryNonIdempotent = true },
+			attempts: 2,
+		},
+		"PATCH not retried": {
+			call: update, endpoint: gomojotest.EndpointUpdateOffer,
+			fault:    gomojotest.Fault{StatusCode: 503, Times: 1},
+			attempts: 1, err: gomojo.ErrServerError,
+		},
+		"PATCH retried with RetryNonIdempotent": {
+			call: update, endpoint: gomojotest.EndpointUpdateOffer,
+			fault:    gomojotest.Fault{StatusCode: 503, Times: 1},
+			change:   func(policy *gomojo.RetryPolicy) { policy.RetryNonIdempotent = true },
+			attempts: 2,
+		},
+		"Retry-After honoured": {
+			call: details, endpoint: gomojotest.EndpointOfferDetails,
+			fault:    gomojotest.Fault{StatusCode: 429, RetryAfter: "1", Times: 1},
+			attempts: 2, min_elapsed: time.Second,
+		},
+		"Retry-After beyond MaxBackoff": {
+			call: details, endpoint: gomojotest.EndpointOfferDetails,
+			fault:    gomojotest.Fault{StatusCode: 429, RetryAfter: "3600", Times: 1},
+			attempts: 1, err: gomojo.ErrRateLimited, max_elapsed: time.Second,
+		},
+		"Retry-After ignored": {
+			call: details, endpoint: gomojotest.EndpointOfferDetails,
+			fault:    gomojotest.Fault{StatusCode: 429, RetryAfter: "3600", Times: 1},
+			change:   func(policy *gomojo.RetryPolicy) { policy.IgnoreRetryAfter = true },
+			attempts: 2, max_elapsed: time.Second,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			srv := gomojotest.NewServer()
+			defer srv.Close()
+
+			srv.SeedOffers(gomojo.Offer{Title: "A", Slug: "a"})
+			srv.SetFault(test.endpoint, test.fault)
+
+			policy := fastRetryPolicy()
+			if test.change != nil {
+				test.change(&policy)
+			}
+
+			start := time.Now()
+			err := test.call(srv.NewClient(gomojo.WithRetryPolicy(policy)))
+			elapsed := time.Since(start)
+
+			if test.err == nil && err != nil {
+				t.Errorf("got %v, want success", err)
+			} else if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("got %v, want %v", err, test.err)
+			}
+
+			if attempts := len(srv.RequestsFor(test.endpoint)); attempts != test.attempts {
+				t.Errorf("got %d attempts, want %d", attempts, test.attempts)
+			}
+
+			if elapsed < test.min_elapsed {
+				t.Errorf("took %v, want at least %v", elapsed, test.min_elapsed)
+			}
+			if test.max_elapsed > 0 && elapsed > test.max_elapsed {
+				t.Errorf("took %v, want at most %v", elapsed, test.max_elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TokenStore: persists Auth Tokens, keyed by App ID
+// LoadToken returns a blank Auth Token (and no error) when none is stored.
+type TokenStore interface {
+	LoadToken(app_id string) (string, error)
+	SaveToken(app_id, auth_token string) error
+	DeleteToken(app_id string) error
+}
+
+// WithTokenStore: load the Auth Token from (and save new ones to) store
+// The stored Auth Token is used when no Auth Token was given to the Client.
+// Auth Tokens obtained by the Client are saved, and deleted from the store
+// again when DeleteAuthToken is called with them.
+func WithTokenStore(store TokenStore) ClientOption {
+	return func(c *Client) {
+		c.tokens = store
+	}
+}
+
+// MemoryTokenStore: a TokenStore keeping Auth Tokens in memory
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewMemoryTokenStore: creates an empty MemoryTokenStore
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]string)}
+}
+
+// LoadToken: implements TokenStore
+func (s *MemoryTokenStore) LoadToken(app_id string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[app_id], nil
+}
+
+// SaveToken: implements TokenStore
+func (s *MemoryTokenStore) SaveToken(app_id, auth_token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[app_id] = auth_token
+	return nil
+}
+
+// DeleteToken: implements TokenStore
+func (s *MemoryTokenStore) DeleteToken(app_id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, app_id)
+	return nil
+}
+
+// FileTokenStore: a TokenStore keeping Auth Tokens in a JSON file,
+// readable and writable only by its owner (0600). The file can optionally
+// be encrypted with a passphrase (see NewEncryptedFileTokenStore).
+type FileTokenStore struct {
+	path       string
+	encrypted  bool
+	passphrase string
+	mu         sync.Mutex
+}
+
+// tokenFile: contents of a (decrypted) token file
+type tokenFile struct {
+	Tokens map[string]string `json:"tokens"`
+}
+
+// encryptedTokenFile: contents of an encrypted token file
+// Data is the AES-256-GCM sealed tokenFile JSON, with the key derived from
+// the passphrase by PBKDF2-SHA256 with Salt and Iterations.
+type encryptedTokenFile struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// token_file_kdf/token_file_iterations: key derivation for encrypted token files
+const token_file_kdf = "pbkdf2-sha256"
+const token_file_iterations = 600000
+
+// ErrTokenFileDecrypt: an encrypted token file could not be decrypted
+// (wrong passphrase, or corrupted file)
+var ErrTokenFileDecrypt = errors.New("gomojo: unable to decrypt token file (wrong passphrase?)")
+
+// ErrEmptyPassphrase: an encrypted FileTokenStore was created without a passphrase
+var ErrEmptyPassphrase = errors.New("gomojo: empty passphrase for encrypted token file")
+
+// NewFileTokenStore: creates a FileTokenStore using the plain JSON file at path
+// The file is created on the first SaveToken.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// NewEncryptedFileTokenStore: creates a FileTokenStore using the file at path,
+// encrypted with AES-GCM using a key derived from passphrase
+// With an empty passphrase, every operation of the store fails with
+// ErrEmptyPassphrase (the tokens are never written in plain text).
+func NewEncryptedFileTokenStore(path, passphrase string) *FileTokenStore {
+	return &FileTokenStore{path: path, encrypted: true, passphrase: passphrase}
+}
+
+// LoadToken: implements TokenStore
+func (s *FileTokenStore) LoadToken(app_id string) (string, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return "", err
+	}
+
+	return tokens.Tokens[app_id], nil
+}
+
+// SaveToken: implements TokenStore
+func (s *FileTokenStore) SaveToken(app_id, auth_token string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	tokens.Tokens[app_id] = auth_token
+
+	return s.write(tokens)
+}
+
+// DeleteToken: implements TokenStore
+func (s *FileTokenStore) DeleteToken(app_id string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := tokens.Tokens[app_id]; !ok {
+		return nil
+	}
+	delete(tokens.Tokens, app_id)
+
+	return s.write(tokens)
+}
+
+// read: reads (and decrypts) the token file; a missing file has no tokens
+func (s *FileTokenStore) read() (*tokenFile, error) {
+
+	if s.encrypted && s.passphrase == "" {
+		return nil, ErrEmptyPassphrase
+	}
+
+	tokens := &tokenFile{Tokens: make(map[string]string)}
+
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.encrypted {
+		if data, err = s.decrypt(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = json.Unmarshal(data, tokens); err != nil {
+		return nil, fmt.Errorf("gomojo: invalid token file %s: %w", s.path, err)
+	}
+	if tokens.Tokens == nil {
+		tokens.Tokens = make(map[string]string)
+	}
+
+	return tokens, nil
+}
+
+// write: (encrypts and) atomically replaces the token file, with 0600 permissions
+func (s *FileTokenStore) write(tokens *tokenFile) error {
+
+	if s.encrypted && s.passphrase == "" {
+		return ErrEmptyPassphrase
+	}
+
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if s.encrypted {
+		if data, err = s.encrypt(data); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// TempFile already creates the file with 0600, but be explicit about it
+	if err = tmp.Chmod(0600); err == nil {
+		_, err = tmp.Write(data)
+	}
+	if close_err := tmp.Close(); err == nil {
+		err = close_err
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// encrypt: seals plaintext into an encryptedTokenFile JSON
+func (s *FileTokenStore) encrypt(plaintext []byte) ([]byte, error) {
+
+	enc := encryptedTokenFile{
+		KDF:        token_file_kdf,
+		Iterations: token_file_iterations,
+		Salt:       make([]byte, 16),
+	}
+
+	if _, err := rand.Read(enc.Salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := s.aead(enc.Salt, enc.Iterations)
+	if err != nil {
+		return nil, err
+	}
+
+	enc.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(enc.Nonce); err != nil {
+		return nil, err
+	}
+
+	enc.Data = aead.Seal(nil, enc.Nonce, plaintext, []byte(enc.KDF))
+
+	return json.MarshalIndent(enc, "", "  ")
+}
+
+// decrypt: opens an encryptedTokenFile JSON
+func (s *FileTokenStore) decrypt(data []byte) ([]byte, error) {
+
+	var enc encryptedTokenFile
+
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return nil, fmt.Errorf("gomojo: invalid encrypted token file %s: %w", s.path, err)
+	}
+	if enc.KDF != token_file_kdf || enc.Iterations <= 0 {
+		return nil, fmt.Errorf("gomojo: unsupported encrypted token file %s (kdf %q)", s.path, enc.KDF)
+	}
+
+	aead, err := s.aead(enc.Salt, enc.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(enc.Nonce) != aead.NonceSize() {
+		return nil, ErrTokenFileDecrypt
+	}
+
+	plaintext, err := aead.Open(nil, enc.Nonce, enc.Data, []byte(enc.KDF))
+	if err != nil {
+		return nil, ErrTokenFileDecrypt
+	}
+
+	return plaintext, nil
+}
+
+// aead: AES-256-GCM with the key derived from the passphrase
+func (s *FileTokenStore) aead(salt []byte, iterations int) (cipher.AEAD, error) {
+
+	key, err := pbkdf2.Key(sha256.New, s.passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,62 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dotmanish/gomojo"
+)
+
+func TestEncryptedFileTokenStoreRejectsEmptyPassphrase(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "token")
+	store := gomojo.NewEncryptedFileTokenStore(path, "")
+
+	if err := store.SaveToken("app", "SECRET-TOKEN"); !errors.Is(err, gomojo.ErrEmptyPassphrase) {
+		t.Errorf("SaveToken: got %v, want ErrEmptyPassphrase", err)
+	}
+	if _, err := store.LoadToken("app"); !errors.Is(err, gomojo.ErrEmptyPassphrase) {
+		t.Errorf("LoadToken: got %v, want ErrEmptyPassphrase", err)
+	}
+	if err := store.DeleteToken("app"); !errors.Is(err, gomojo.ErrEmptyPassphrase) {
+		t.Errorf("DeleteToken: got %v, want ErrEmptyPassphrase", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("token file was written: %v", err)
+	}
+}
+
+func TestEncryptedFileTokenStore(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "token")
+
+	if err := gomojo.NewEncryptedFileTokenStore(path, "passphrase").SaveToken("app", "SECRET-TOKEN"); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading token file: %v", err)
+	}
+	if strings.Contains(string(data), "SECRET-TOKEN") {
+		t.Errorf("token file contains the Auth Token in plain text: %s", data)
+	}
+
+	token, err := gomojo.NewEncryptedFileTokenStore(path, "passphrase").LoadToken("app")
+	if err != nil || token != "SECRET-TOKEN" {
+		t.Errorf("LoadToken: got %q, %v, want SECRET-TOKEN", token, err)
+	}
+
+	if _, err = gomojo.NewEncryptedFileTokenStore(path, "wrong").LoadToken("app"); !errors.Is(err, gomojo.ErrTokenFileDecrypt) {
+		t.Errorf("LoadToken with wrong passphrase: got %v, want ErrTokenFileDecrypt", err)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Default timeouts of the HTTP transport used by a Client.
+// There is no overall per-request timeout by default (so that large uploads
+// are not cut short); use a context deadline or WithTimeout for that.
+const (
+	DefaultDialTimeout           = 10 * time.Second
+	DefaultTLSHandshakeTimeout   = 10 * time.Second
+	DefaultResponseHeaderTimeout = 60 * time.Second
+	DefaultIdleConnTimeout       = 90 * time.Second
+)
+
+// default_transport: shared by all Clients not customizing their transport,
+// so that keep-alive connections are reused across calls and Clients
+var default_transport = newTransport()
+
+// newTransport: creates an HTTP transport with the default timeouts
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   DefaultDialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		TLSHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+		ResponseHeaderTimeout: DefaultResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+}
+
+// WithHTTPClient: use the given http.Client for all API calls
+// The transport options (WithTransport, WithTimeout, WithProxy, WithRootCAs,
+// WithTLSMinVersion) have no effect on an http.Client given this way.
+func WithHTTPClient(http_client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = http_client
+	}
+}
+
+// WithTransport: send all API calls through the given http.RoundTripper
+// The options WithProxy, WithRootCAs and WithTLSMinVersion have no effect
+// on a RoundTripper given this way.
+func WithTransport(round_tripper http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.roundTripper = round_tripper
+	}
+}
+
+// WithTimeout: limit each HTTP request (including reading its response)
+// to the given duration. By default, there is no such overall limit.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithProxy: send all API calls through the given HTTP(S) proxy
+// By default, the proxy is taken from the environment (HTTPS_PROXY, ...).
+func WithProxy(proxy_url *url.URL) ClientOption {
+	return func(c *Client) {
+		c.ownTransport().Proxy = http.ProxyURL(proxy_url)
+	}
+}
+
+// WithRootCAs: verify the API server certificates against the given
+// CA roots instead of the system roots
+func WithRootCAs(root_cas *x509.CertPool) ClientOption {
+	return func(c *Client) {
+		c.ownTransport().TLSClientConfig.RootCAs = root_cas
+	}
+}
+
+// WithTLSMinVersion: the minimum TLS version to accept, e.g. tls.VersionTLS13
+// The default is TLS 1.2.
+func WithTLSMinVersion(version uint16) ClientOption {
+	return func(c *Client) {
+		c.ownTransport().TLSClientConfig.MinVersion = version
+	}
+}
+
+// ownTransport: returns the transport of this Client, creating it (instead
+// of sharing default_transport) when the Client customizes it
+func (c *Client) ownTransport() *http.Transport {
+
+	if c.transport == nil {
+		c.transport = newTransport()
+	}
+
+	return c.transport
+}
+
+// initHTTPClient: sets up the http.Client of this Client, unless one was given
+func (c *Client) initHTTPClient() {
+
+	if c.httpClient != nil {
+		return
+	}
+
+	round_tripper := c.roundTripper
+	if round_tripper == nil && c.transport != nil {
+		round_tripper = c.transport
+	}
+	if round_tripper == nil {
+		round_tripper = default_transport
+	}
+
+	c.httpClient = &http.Client{
+		Transport: round_tripper,
+		Timeout:   c.timeout,
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sync"
+)
+
+// uploadFormField: name of the multipart form field carrying the file
+const uploadFormField = "fileUpload"
+
+// UploadProgressFunc: reports the progress of an upload
+// name is the file name given to UploadReader (the base name for UploadFile),
+// sent the number of bytes of content sent so far, and total the size
+// given to UploadReader (-1 if unknown).
+type UploadProgressFunc func(name string, sent, total int64)
+
+// WithUploadProgress: call progress as the content of each upload is sent
+// progress is called from the goroutine streaming the upload body.
+func WithUploadProgress(progress UploadProgressFunc) ClientOption {
+	return func(c *Client) {
+		c.uploadProgress = progress
+	}
+}
+
+// UploadReader: uploads a File (content) or Cover Image read from r
+// size is the exact number of bytes r will yield, used for the Content-Length
+// of the upload POST and to report progress. If unknown, pass -1: the body is
+// then sent chunked, which some upload servers refuse.
+// UploadFile passes the file's size.
+// Inputs: (File Name string, Reader, Size int64)
+// Returns: (UploadResult object, error)
+func (c *Client) UploadReader(file_name string, r io.Reader, size int64) (UploadResult, error) {
+	return c.UploadReaderContext(context.Background(), file_name, r, size)
+}
+
+// UploadReaderContext: same as UploadReader, but the API request(s) are bound to ctx
+// and aborted when ctx is cancelled or its deadline passes.
+// Inputs: (Context, File Name string, Reader, Size int64)
+// Returns: (UploadResult object, error)
+func (c *Client) UploadReaderContext(ctx context.Context, file_name string, r io.Reader, size int64) (UploadResult, error) {
+
+	if !c.initDone {
+		return UploadResult{}, ErrNotInitialized
+	}
+
+	jsonobj := NewEnvelope[string]("upload_url")
+
+	err := c.callAPI(ctx, "getfileuploadurl", "", nil, jsonobj)
+	if err != nil {
+		return UploadResult{UploadURL: jsonobj.Data}, err
+	}
+	if jsonobj.Data == "" {
+		return UploadResult{}, &APIError{
+			StatusCode: http.StatusOK,
+			Method:     endpoints["getfileuploadurl"].method,
+			Endpoint:   endpoints["getfileuploadurl"].path,
+			Message:    "No upload_url in response",
+		}
+	}
+
+	// The upload URL may be relative to the API root (e.g. on a local stand-in)
+	upload_url, err := c.resolveURL(jsonobj.Data)
+	if err != nil {
+		return UploadResult{UploadURL: jsonobj.Data}, err
+	}
+	result := &UploadResult{UploadURL: upload_url}
+
+	if size < 0 {
+		size = -1
+	}
+
+	if c.uploadProgress != nil {
+		r = &progressReader{reader: r, name: file_name, total: size, progress: c.uploadProgress}
+	}
+
+	body := newMultipartBody(r, file_name, size)
+	defer body.Close()
+
+	request, err := http.NewRequestWithContext(ctx, "POST", result.UploadURL, body)
+	if err != nil {
+		return *result, uploadError(transportError("POST", result.UploadURL, err))
+	}
+	request.Header.Set("Content-Type", body.contentType)
+	request.ContentLength = body.contentLength
+
+	call := &Call{
+		Operation: "upload",
+		Endpoint:  redactEndpoint(result.UploadURL),
+		Request:   request,
+		Result:    result,
+	}
+
+	err = c.intercept(c.roundTripUpload)(call)
+	if err != nil {
+		// A failure to read the content surfaces as a transport error;
+		// report the underlying cause instead
+		if read_err := body.readError(); read_err != nil {
+			err = fmt.Errorf("reading %s: %w", file_name, read_err)
+		}
+		return *result, uploadError(err)
+	}
+
+	return *result, nil
+}
+
+// progressReader: reports the bytes read through it to progress
+type progressReader struct {
+	reader   io.Reader
+	name     string
+	sent     int64
+	total    int64
+	progress UploadProgressFunc
+}
+
+// Read: implements io.Reader
+func (p *progressReader) Read(buf []byte) (int, error) {
+
+	n, err := p.reader.Read(buf)
+	if n > 0 {
+		p.sent += int64(n)
+		p.progress(p.name, p.sent, p.total)
+	}
+
+	return n, err
+}
+
+// multipartBody: streams content as the file of a multipart/form-data body
+// The body is written by a goroutine into an io.Pipe as the HTTP client
+// reads it, so memory use stays constant regardless of the file size.
+// The caller must Close the body, which also stops the goroutine if the
+// request was never sent. An error reading content fails the read of the
+// body (and hence the request), and is kept for readError.
+type multipartBody struct {
+	*io.PipeReader
+	contentType   string // including the boundary
+	contentLength int64  // -1 if the size of the content is unknown
+
+	mu       sync.Mutex
+	read_err error
+}
+
+// newMultipartBody: starts streaming content (of size bytes, -1 if unknown) as file_name
+func newMultipartBody(content io.Reader, file_name string, size int64) *multipartBody {
+
+	pipe_reader, pipe_writer := io.Pipe()
+	writer := multipart.NewWriter(pipe_writer)
+
+	body := &multipartBody{
+		PipeReader:    pipe_reader,
+		contentType:   writer.FormDataContentType(),
+		contentLength: -1,
+	}
+
+	if size >= 0 {
+		body.contentLength = multipartOverhead(writer.Boundary(), file_name) + size
+	}
+
+	go func() {
+		part, err := writer.CreateFormFile(uploadFormField, file_name)
+		if err == nil {
+			_, err = io.Copy(part, readerFunc(func(buf []byte) (int, error) {
+				n, read_err := content.Read(buf)
+				if read_err != nil && read_err != io.EOF {
+					body.mu.Lock()
+					body.read_err = read_err
+					body.mu.Unlock()
+				}
+				return n, read_err
+			}))
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		pipe_writer.CloseWithError(err)
+	}()
+
+	return body
+}
+
+// multipartOverhead: the length of the multipart body around the content,
+// i.e. the part header and the closing boundary
+func multipartOverhead(boundary, file_name string) int64 {
+
+	var overhead bytes.Buffer
+	writer := multipart.NewWriter(&overhead)
+	writer.SetBoundary(boundary)
+	writer.CreateFormFile(uploadFormField, file_name)
+	writer.Close()
+
+	return int64(overhead.Len())
+}
+
+// readError: the error reading the content, if any
+func (b *multipartBody) readError() error {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.read_err
+}
+
+// readerFunc: adapts a function to io.Reader
+type readerFunc func([]byte) (int, error)
+
+// Read: implements io.Reader
+func (f readerFunc) Read(buf []byte) (int, error) {
+	return f(buf)
+}
@@ -0,0 +1,246 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dotmanish/gomojo"
+	"github.com/dotmanish/gomojo/gomojotest"
+)
+
+// patternReader: generates size bytes of a repeating pattern, without
+// holding them in memory
+type patternReader struct {
+	size int64
+	read int64
+}
+
+// Read: implements io.Reader
+func (p *patternReader) Read(buf []byte) (int, error) {
+
+	if p.read >= p.size {
+		return 0, io.EOF
+	}
+	if remaining := p.size - p.read; int64(len(buf)) > remaining {
+		buf = buf[:remaining]
+	}
+
+	for i := range buf {
+		buf[i] = byte((p.read + int64(i)) % 251)
+	}
+	p.read += int64(len(buf))
+
+	return len(buf), nil
+}
+
+// checkPattern: whether content is what patternReader generates
+func checkPattern(t *testing.T, content []byte) {
+
+	t.Helper()
+
+	for i, b := range content {
+		if b != byte(i%251) {
+			t.Fatalf("uploaded content differs at byte %d", i)
+		}
+	}
+}
+
+func TestUploadReaderStreamsLargeContent(t *testing.T) {
+
+	const size = 32 << 20
+
+	for _, test := range []struct {
+		name  string
+		size  int64 // as passed to UploadReader
+		sized bool  // whether a Content-Length is expected
+	}{
+		{"known size", size, true},
+		{"unknown size", -1, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+
+			srv := gomojotest.NewServer()
+			defer srv.Close()
+
+			result, err := srv.NewClient().UploadReader("large.bin", &patternReader{size: size}, test.size)
+			if err != nil {
+				t.Fatalf("UploadReader: %v", err)
+			}
+			if result.Size != size {
+				t.Errorf("upload result size = %d, want %d", result.Size, size)
+			}
+
+			uploads := srv.Uploads()
+			if len(uploads) != 1 || len(uploads[0].Content) != size {
+				t.Fatalf("server received %d uploads, want 1 of %d bytes", len(uploads), size)
+			}
+			checkPattern(t, uploads[0].Content)
+
+			requests := srv.RequestsFor(gomojotest.EndpointUpload)
+			content_length := requests[len(requests)-1].ContentLength
+			if test.sized && content_length <= size {
+				t.Errorf("upload Content-Length = %d, want the multipart body length", content_length)
+			}
+			if !test.sized && content_length != -1 {
+				t.Errorf("upload Content-Length = %d, want -1 (chunked)", content_length)
+			}
+		})
+	}
+}
+
+func TestUploadFileSendsContentLength(t *testing.T) {
+
+	file_path := filepath.Join(t.TempDir(), "cover.jpg")
+	file, err := os.Create(file_path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = io.Copy(file, &patternReader{size: 1 << 20}); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	srv := gomojotest.NewServer()
+	defer srv.Close()
+
+	if _, err = srv.NewClient().UploadFile(file_path); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	requests := srv.RequestsFor(gomojotest.EndpointUpload)
+	if content_length := requests[0].ContentLength; content_length <= 1<<20 {
+		t.Errorf("upload Content-Length = %d, want the multipart body length", content_length)
+	}
+
+	uploads := srv.Uploads()
+	if uploads[0].FileName != "cover.jpg" || len(uploads[0].Content) != 1<<20 {
+		t.Errorf("server received %q of %d bytes", uploads[0].FileName, len(uploads[0].Content))
+	}
+}
+
+// failingReader: yields some content, then fails
+type failingReader struct {
+	sent int
+	err  error
+}
+
+// Read: implements io.Reader
+func (f *failingReader) Read(buf []byte) (int, error) {
+
+	if f.sent >= 3 {
+		return 0, f.err
+	}
+	f.sent++
+
+	return copy(buf, "chunk"), nil
+}
+
+func TestUploadErrors(t *testing.T) {
+
+	disk_err := errors.New("disk failure")
+
+	for _, test := range []struct {
+		name        string
+		endpoint    string // where the fault is injected
+		fault       gomojotest.Fault
+		content     io.Reader
+		upload_fail bool   // whether the error matches ErrUploadFailed
+		invalid     bool   // whether the error matches ErrInvalidResponse
+		status_code int    // of the *APIError (0: no *APIError)
+		message     string // of the *APIError
+	}{
+		{
+			name:     "non-2xx with JSON message",
+			endpoint: gomojotest.EndpointUpload,
+			fault:    gomojotest.Fault{StatusCode: 413, Message: "File too large."},
+			content:  strings.NewReader("content"), upload_fail: true, status_code: 413, message: "File too large.",
+		},
+		{
+			name:     "non-2xx with HTML",
+			endpoint: gomojotest.EndpointUpload,
+			fault:    gomojotest.Fault{StatusCode: 502, Body: "<html><body>Bad Gateway</body></html>"},
+			content:  strings.NewReader("content"), upload_fail: true, status_code: 502,
+		},
+		{
+			name:     "2xx with non-JSON body",
+			endpoint: gomojotest.EndpointUpload,
+			fault:    gomojotest.Fault{StatusCode: 200, Body: "OK"},
+			content:  strings.NewReader("content"), upload_fail: true, invalid: true, status_code: 200,
+			message: "Invalid upload response: not a JSON object",
+		},
+		{
+			name:     "2xx with success false",
+			endpoint: gomojotest.EndpointUpload,
+			fault:    gomojotest.Fault{StatusCode: 200, Body: `{"success": false, "message": "Virus detected."}`},
+			content:  strings.NewReader("content"), upload_fail: true, status_code: 200, message: "Virus detected.",
+		},
+		{
+			name:    "reader error mid-stream",
+			content: &failingReader{err: disk_err}, upload_fail: true,
+		},
+		{
+			name:     "get_file_upload_url failure",
+			endpoint: gomojotest.EndpointGetFileUploadURL,
+			fault:    gomojotest.Fault{StatusCode: 503, Message: "Service unavailable."},
+			content:  strings.NewReader("content"), status_code: 503, message: "Service unavailable.",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+
+			srv := gomojotest.NewServer()
+			defer srv.Close()
+
+			if test.endpoint != "" {
+				srv.SetFault(test.endpoint, test.fault)
+			}
+
+			_, err := srv.NewClient().UploadReader("file.txt", test.content, -1)
+			if err == nil {
+				t.Fatal("UploadReader succeeded, want an error")
+			}
+
+			if got := errors.Is(err, gomojo.ErrUploadFailed); got != test.upload_fail {
+				t.Errorf("errors.Is(%v, ErrUploadFailed) = %v, want %v", err, got, test.upload_fail)
+			}
+
+			if got := errors.Is(err, gomojo.ErrInvalidResponse); got != test.invalid {
+				t.Errorf("errors.Is(%v, ErrInvalidResponse) = %v, want %v", err, got, test.invalid)
+			}
+
+			var api_err *gomojo.APIError
+			if test.status_code == 0 {
+				if errors.As(err, &api_err) {
+					t.Errorf("got *APIError %v, want none", api_err)
+				}
+			} else if !errors.As(err, &api_err) {
+				t.Errorf("got %v, want an *APIError", err)
+			} else {
+				if api_err.StatusCode != test.status_code {
+					t.Errorf("StatusCode = %d, want %d", api_err.StatusCode, test.status_code)
+				}
+				if test.message != "" && api_err.Message != test.message {
+					t.Errorf("Message = %q, want %q", api_err.Message, test.message)
+				}
+			}
+
+			if test.content != nil && test.status_code == 0 && !errors.Is(err, disk_err) {
+				t.Errorf("got %v, want the reader's error", err)
+			}
+
+			// The server may not see a POST aborted by the reader's error
+			uploaded := len(srv.RequestsFor(gomojotest.EndpointUpload)) > 0
+			if uploaded != test.upload_fail && test.status_code != 0 {
+				t.Errorf("upload POST received = %v, want %v", uploaded, test.upload_fail)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// UploadResult: the outcome of UploadFile/UploadReader
+// JSON is the upload server's response as returned, which is what the
+// API expects in Offer.FileUploadJSON or Offer.CoverImageJSON
+// (see AttachFile and AttachCoverImage). The other fields are decoded from it.
+type UploadResult struct {
+	UploadURL string `json:"-"` // where the file was uploaded to
+	JSON      string `json:"-"`
+
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+	FileID   string `json:"file_id"`
+	FileName string `json:"filename"`
+	Size     int64  `json:"size"`
+
+	// Extra: fields of the response that UploadResult does not know (yet)
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// ParseUploadResult: decodes the upload server's response (e.g. as kept in
+// Offer.FileUploadJSON), checking that it is a JSON object from a
+// successful upload (errors.Is(err, ErrInvalidResponse) if it is malformed)
+// Inputs: (Upload-File JSON string)
+// Returns: (UploadResult object, error)
+func ParseUploadResult(upload_json string) (UploadResult, error) {
+
+	var result UploadResult
+	err := result.parse([]byte(upload_json))
+
+	return result, err
+}
+
+// invalidUploadResponse: describes a malformed upload response
+// errors.Is(err, ErrInvalidResponse) holds for it.
+type invalidUploadResponse string
+
+// Error: implements the error interface
+func (e invalidUploadResponse) Error() string {
+	return "Invalid upload response: " + string(e)
+}
+
+// Is: matches ErrInvalidResponse
+func (e invalidUploadResponse) Is(target error) bool {
+	return target == ErrInvalidResponse
+}
+
+// parse: fills in r from the upload server's response body
+// A response without "success" is accepted, as long as it is a JSON object.
+// A malformed response is an invalidUploadResponse error, whereas an
+// unsuccessful upload is an error with the upload server's message.
+func (r *UploadResult) parse(body []byte) error {
+
+	body = bytes.TrimSpace(body)
+	r.JSON = string(body)
+
+	if len(body) == 0 || body[0] != '{' {
+		return invalidUploadResponse("not a JSON object")
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return invalidUploadResponse(err.Error())
+	}
+
+	if err := json.Unmarshal(body, r); err != nil {
+		return invalidUploadResponse(err.Error())
+	}
+
+	if _, ok := fields["success"]; ok && !r.Success {
+		if r.Message != "" {
+			return errors.New(r.Message)
+		}
+		return errors.New("Upload failed")
+	}
+
+	return nil
+}
+
+// AttachFile: sets the uploaded file as the offer's File (content)
+func (o *Offer) AttachFile(result UploadResult) {
+	o.FileUploadJSON = result.JSON
+}
+
+// AttachCoverImage: sets the uploaded file as the offer's Cover Image
+func (o *Offer) AttachCoverImage(result UploadResult) {
+	o.CoverImageJSON = result.JSON
+}
+
+// AttachFile: sets the uploaded file as the offer's File (content)
+func (p *OfferPatch) AttachFile(result UploadResult) {
+	p.FileUploadJSON = String(result.JSON)
+}
+
+// AttachCoverImage: sets the uploaded file as the offer's Cover Image
+func (p *OfferPatch) AttachCoverImage(result UploadResult) {
+	p.CoverImageJSON = String(result.JSON)
+}
@@ -0,0 +1,195 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// SupportedCurrencies: currencies accepted by Validate for an Offer
+var SupportedCurrencies = []string{"INR", "USD"}
+
+// ErrInvalidOffer: an Offer (or OfferPatch) failed client-side validation
+// The error returned is a *ValidationError, listing the problems per field.
+var ErrInvalidOffer = errors.New("gomojo: invalid offer")
+
+// FieldError: one problem with one field of an Offer
+type FieldError struct {
+	Field   string // API field name, e.g. "base_price"
+	Message string
+}
+
+// Error: implements the error interface
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError: all problems found by Validate
+// errors.Is(err, ErrInvalidOffer) holds for it, and errors.As can retrieve
+// the individual *FieldError values.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+// Error: implements the error interface
+func (e *ValidationError) Error() string {
+
+	problems := make([]string, len(e.Errors))
+	for i, field_err := range e.Errors {
+		problems[i] = field_err.Error()
+	}
+
+	return ErrInvalidOffer.Error() + ": " + strings.Join(problems, "; ")
+}
+
+// Is: matches ErrInvalidOffer
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidOffer
+}
+
+// Unwrap: the individual *FieldError values
+func (e *ValidationError) Unwrap() []error {
+
+	errs := make([]error, len(e.Errors))
+	for i, field_err := range e.Errors {
+		errs[i] = field_err
+	}
+
+	return errs
+}
+
+// WithoutValidation: do not Validate offers in CreateOffer/UpdateOffer
+// before sending them
+func WithoutValidation() ClientOption {
+	return func(c *Client) {
+		c.skipValidation = true
+	}
+}
+
+// Validate: checks the Offer for mistakes the API would reject, such as
+// a blank title, a non-numeric base_price, an end_date before start_date,
+// an unsupported currency or a non-https redirect_url.
+// The timezone is looked up in the zoneinfo of the host (see Offer.Location).
+// Returns nil, or a *ValidationError listing the problems per field.
+// CreateOffer calls it automatically, unless WithoutValidation is given.
+func (o Offer) Validate() error {
+	return validateOffer(o, func(string) bool { return true })
+}
+
+// Validate: checks the fields set in the OfferPatch, like Offer.Validate
+// (start_date/end_date are only compared when both are set).
+// UpdateOffer calls it automatically, unless WithoutValidation is given.
+func (p OfferPatch) Validate() error {
+
+	form := encodeForm(p)
+
+	// Gather the set fields into an Offer
+	var offer Offer
+	offer_value := reflect.ValueOf(&offer).Elem()
+	patch_value := reflect.ValueOf(p)
+	for i := 0; i < patch_value.NumField(); i++ {
+		if field := patch_value.Field(i); !field.IsNil() {
+			offer_value.FieldByName(patch_value.Type().Field(i).Name).SetString(field.Elem().String())
+		}
+	}
+
+	return validateOffer(offer, func(field string) bool {
+		_, ok := form[field]
+		return ok
+	})
+}
+
+// validateOffer: validates the fields of offer for which is_set is true
+func validateOffer(offer Offer, is_set func(field string) bool) error {
+
+	v := new(ValidationError)
+	problem := func(field, message string) {
+		v.Errors = append(v.Errors, &FieldError{Field: field, Message: message})
+	}
+
+	if is_set("title") && strings.TrimSpace(offer.Title) == "" {
+		problem("title", "must not be blank")
+	}
+
+	if is_set("currency") && offer.Currency != "" {
+		supported := false
+		for _, currency := range SupportedCurrencies {
+			supported = supported || offer.Currency == currency
+		}
+		if !supported {
+			problem("currency", "unsupported currency "+offer.Currency+" (supported: "+strings.Join(SupportedCurrencies, ", ")+")")
+		}
+	}
+
+	if is_set("base_price") {
+		if price, err := offer.Price(); err != nil {
+			problem("base_price", "must be a decimal amount like 499.50")
+		} else if price < 0 {
+			problem("base_price", "must not be negative")
+		}
+	}
+
+	if is_set("quantity") {
+		if _, err := offer.Qty(); err != nil {
+			problem("quantity", "must be a whole number, or blank for unlimited")
+		}
+	}
+
+	timezone_ok := true
+	if is_set("timezone") {
+		if _, err := offer.Location(); err != nil {
+			problem("timezone", "unknown timezone "+offer.Timezone)
+			timezone_ok = false
+		}
+	}
+
+	var start_ok, end_ok bool
+	if is_set("start_date") && timezone_ok {
+		if _, err := offer.StartTime(); err != nil {
+			problem("start_date", "must be formatted like "+OfferDateFormat)
+		} else {
+			start_ok = offer.StartDate != ""
+		}
+	}
+	if is_set("end_date") && timezone_ok {
+		if _, err := offer.EndTime(); err != nil {
+			problem("end_date", "must be formatted like "+OfferDateFormat)
+		} else {
+			end_ok = offer.EndDate != ""
+		}
+	}
+	if start_ok && end_ok {
+		start_time, _ := offer.StartTime()
+		end_time, _ := offer.EndTime()
+		if end_time.Before(start_time) {
+			problem("end_date", "must not be before start_date")
+		}
+	}
+
+	if is_set("redirect_url") && offer.RedirectURL != "" {
+		redirect_url, err := url.Parse(offer.RedirectURL)
+		if err != nil || redirect_url.Scheme != "https" || redirect_url.Host == "" {
+			problem("redirect_url", "must be an absolute https:// URL")
+		}
+	}
+
+	if is_set("file_upload_json") && offer.FileUploadJSON != "" && !json.Valid([]byte(offer.FileUploadJSON)) {
+		problem("file_upload_json", "must be the JSON returned by UploadFile")
+	}
+	if is_set("cover_image_json") && offer.CoverImageJSON != "" && !json.Valid([]byte(offer.CoverImageJSON)) {
+		problem("cover_image_json", "must be the JSON returned by UploadFile")
+	}
+
+	if len(v.Errors) > 0 {
+		return v
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2013 Manish Malik (manishmalik.name)
+// All rights reserved.
+// Use of this source code is governed by a BSD (3-Clause) License
+// that can be found in the LICENSE file.
+
+package gomojo_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	// The test offers use Asia/Kolkata, whatever the zoneinfo of the host
+	_ "time/tzdata"
+
+	"github.com/dotmanish/gomojo"
+)
+
+// validOffer: an Offer passing Validate, with the given change applied
+func validOffer(change func(o *gomojo.Offer)) gomojo.Offer {
+
+	offer := gomojo.Offer{
+		Title:       "Test Product",
+		Currency:    "INR",
+		BasePrice:   "499.50",
+		Quantity:    "10",
+		StartDate:   "2024-01-01 10:00",
+		EndDate:     "2024-01-02 10:00",
+		Timezone:    "Asia/Kolkata",
+		RedirectURL: "https://example.com/thanks",
+	}
+	if change != nil {
+		change(&offer)
+	}
+
+	return offer
+}
+
+// invalidFields: the fields err reports problems for (nil if err is nil)
+func invalidFields(t *testing.T, err error) []string {
+
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, gomojo.ErrInvalidOffer) {
+		t.Fatalf("got %v, want ErrInvalidOffer", err)
+	}
+
+	var field_err *gomojo.FieldError
+	if !errors.As(err, &field_err) {
+		t.Errorf("errors.As(%v) found no *FieldError", err)
+	}
+
+	var fields []string
+	for _, problem := range err.(*gomojo.ValidationError).Errors {
+		fields = append(fields, problem.Field)
+	}
+
+	return fields
+}
+
+func TestOfferValidate(t *testing.T) {
+
+	tests := map[string]struct {
+		change func(o *gomojo.Offer)
+		fields []string
+	}{
+		"valid":                {},
+		"minimal":              {change: func(o *gomojo.Offer) { *o = gomojo.Offer{Title: "Test Product", BasePrice: "10"} }},
+		"blank title":          {change: func(o *gomojo.Offer) { o.Title = "  " }, fields: []string{"title"}},
+		"unsupported currency": {change: func(o *gomojo.Offer) { o.Currency = "EUR" }, fields: []string{"currency"}},
+		"non-numeric price":    {change: func(o *gomojo.Offer) { o.BasePrice = "abc" }, fields: []string{"base_price"}},
+		"too many decimals":    {change: func(o *gomojo.Offer) { o.BasePrice = "1.999" }, fields: []string{"base_price"}},
+		"negative price":       {change: func(o *gomojo.Offer) { o.BasePrice = "-1.00" }, fields: []string{"base_price"}},
+		"fractional quantity":  {change: func(o *gomojo.Offer) { o.Quantity = "1.5" }, fields: []string{"quantity"}},
+		"unlimited quantity":   {change: func(o *gomojo.Offer) { o.Quantity = "" }},
+		"unknown timezone":     {change: func(o *gomojo.Offer) { o.Timezone = "Mars/Olympus_Mons" }, fields: []string{"timezone"}},
+		"bad start_date":       {change: func(o *gomojo.Offer) { o.StartDate = "01/01/2024" }, fields: []string{"start_date"}},
+		"bad end_date":         {change: func(o *gomojo.Offer) { o.EndDate = "2024-01-02T10:00" }, fields: []string{"end_date"}},
+		"end_date before start_date": {
+			change: func(o *gomojo.Offer) { o.EndDate = "2024-01-01 09:59" },
+			fields: []string{"end_date"},
+		},
+		"end_date without start_date": {change: func(o *gomojo.Offer) { o.StartDate = "" }},
+		"http redirect_url":           {change: func(o *gomojo.Offer) { o.RedirectURL = "http://example.com/thanks" }, fields: []string{"redirect_url"}},
+		"relative redirect_url":       {change: func(o *gomojo.Offer) { o.RedirectURL = "/thanks" }, fields: []string{"redirect_url"}},
+		"bad file_upload_json":        {change: func(o *gomojo.Offer) { o.FileUploadJSON = "{" }, fields: []string{"file_upload_json"}},
+		"bad cover_image_json":        {change: func(o *gomojo.Offer) { o.CoverImageJSON = "not json" }, fields: []string{"cover_image_json"}},
+		"several problems": {
+			change: func(o *gomojo.Offer) { o.Title = ""; o.Currency = "EUR"; o.Quantity = "many" },
+			fields: []string{"title", "currency", "quantity"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			fields := invalidFields(t, validOffer(test.change).Validate())
+			if !reflect.DeepEqual(fields, test.fields) {
+				t.Errorf("got problems with %v, want %v", fields, test.fields)
+			}
+		})
+	}
+}
+
+func TestOfferPatchValidate(t *testing.T) {
+
+	tests := map[string]struct {
+		patch  gomojo.OfferPatch
+		fields []string
+	}{
+		"empty":             {},
+		"blank title":       {patch: gomojo.OfferPatch{Title: gomojo.String("")}, fields: []string{"title"}},
+		"price only":        {patch: gomojo.OfferPatch{BasePrice: gomojo.String("99")}},
+		"non-numeric price": {patch: gomojo.OfferPatch{BasePrice: gomojo.String("free")}, fields: []string{"base_price"}},
+		"unknown timezone":  {patch: gomojo.OfferPatch{Timezone: gomojo.String("Nowhere")}, fields: []string{"timezone"}},
+		"end_date only":     {patch: gomojo.OfferPatch{EndDate: gomojo.String("2024-01-01 09:00")}},
+		"end_date before start_date": {
+			patch:  gomojo.OfferPatch{StartDate: gomojo.String("2024-01-02 10:00"), EndDate: gomojo.String("2024-01-01 10:00")},
+			fields: []string{"end_date"},
+		},
+		"http redirect_url": {patch: gomojo.OfferPatch{RedirectURL: gomojo.String("http://example.com/")}, fields: []string{"redirect_url"}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			fields := invalidFields(t, test.patch.Validate())
+			if !reflect.DeepEqual(fields, test.fields) {
+				t.Errorf("got problems with %v, want %v", fields, test.fields)
+			}
+		})
+	}
+}